@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// cliConfig holds the settings read from the config file. Flags and
+// environment variables take precedence over it.
+type cliConfig struct {
+	Storage struct {
+		Backend string `yaml:"backend"` // Storage backend, as for --backend
+	} `yaml:"storage"`
+}
+
+var (
+	configFile string
+	fileConfig cliConfig
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file (or use ORCHESTRATOR_CONFIG env var, default: <user config dir>/orchestrator/config.yaml)")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return loadConfig()
+	}
+}
+
+// loadConfig reads the config file given with --config or ORCHESTRATOR_CONFIG,
+// or the default one if it exists
+func loadConfig() error {
+	path := configFile
+	if path == "" {
+		path = os.Getenv("ORCHESTRATOR_CONFIG")
+	}
+	explicit := path != ""
+	if !explicit {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return nil
+		}
+		path = filepath.Join(dir, "orchestrator", "config.yaml")
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) && !explicit {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, &fileConfig); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return nil
+}
@@ -2,77 +2,110 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/Kobeep/cloud-dr-orchestrator/pkg/metrics"
 	"github.com/Kobeep/cloud-dr-orchestrator/pkg/oracle"
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
 	"github.com/spf13/cobra"
 )
 
 var downloadCmd = &cobra.Command{
 	Use:   "download",
-	Short: "Download a backup file from Oracle Cloud Object Storage",
-	Long: `Download a backup file from Oracle Cloud Object Storage to a local path.
+	Short: "Download a backup file from cloud storage",
+	Long: `Download a backup file from the configured storage backend to a local path.
+
+With the oracle backend the object is fetched with parallel ranged requests
+into <output>.part. An interrupted download resumes when the command is run
+again, and the file is verified against the object's SHA-256/MD5 before it is
+moved to --output. Objects in Archive tier are rehydrated first and the command
+waits until they are readable.
+
+--object may also be an HTTPS URL created with "orchestrator share create";
+it is downloaded without any storage credentials.
 
 Example:
-  orchestrator download --object backups/2025/12/backup-20251209.tar.gz --output ./backup.tar.gz`,
+  orchestrator download --object backups/2025/12/backup-20251209.tar.gz --output ./backup.tar.gz
+  orchestrator download --object 'https://objectstorage.../p/.../o/backups/...' --output ./backup.tar.gz`,
 	RunE: runDownload,
 }
 
 var (
 	downloadObjectName string
 	downloadOutput     string
+	downloadTimeout    time.Duration
 )
 
 func init() {
 	rootCmd.AddCommand(downloadCmd)
 
-	downloadCmd.Flags().StringVar(&downloadObjectName, "object", "", "Object name in Object Storage to download (required)")
+	downloadCmd.Flags().StringVar(&downloadObjectName, "object", "", "Object name in Object Storage, or a shared HTTPS URL, to download (required)")
 	downloadCmd.Flags().StringVar(&downloadOutput, "output", "", "Local path to save the downloaded file (required)")
-	downloadCmd.Flags().StringVar(&ociConfigFile, "oci-config", "", "Path to OCI config file (default: ~/.oci/config)")
-	downloadCmd.Flags().StringVar(&ociProfile, "oci-profile", "DEFAULT", "OCI config profile to use")
-	downloadCmd.Flags().StringVar(&ociBucket, "bucket", "", "OCI Object Storage bucket name (required)")
-	downloadCmd.Flags().StringVar(&ociNamespace, "namespace", "", "OCI namespace (auto-detected if not provided)")
-	downloadCmd.Flags().StringVar(&ociCompartment, "compartment", "", "OCI compartment ID (required)")
+	downloadCmd.Flags().DurationVar(&downloadTimeout, "timeout", 0, "Abort the download after this duration, e.g. 2h (default: no timeout)")
+	addStorageFlags(downloadCmd)
+
+	// Ranged download flags (oracle backend)
+	downloadCmd.Flags().Int64Var(&ociPartSizeMB, "part-size", oracle.DefaultPartSize/1024/1024, "Size in MB of each ranged request")
+	downloadCmd.Flags().IntVar(&ociParallel, "parallel", oracle.DefaultParallelism, "Number of ranges downloaded concurrently")
+	downloadCmd.Flags().IntVar(&ociMaxRetries, "retries", oracle.DefaultMaxRetries, "Attempts per range before giving up")
+	addRehydrateFlags(downloadCmd)
 
 	downloadCmd.MarkFlagRequired("object")
 	downloadCmd.MarkFlagRequired("output")
-	downloadCmd.MarkFlagRequired("bucket")
-	downloadCmd.MarkFlagRequired("compartment")
 }
 
 func runDownload(cmd *cobra.Command, args []string) error {
-	fmt.Printf("🔗 Connecting to Oracle Cloud...\n")
-
-	// Create OCI client
-	config := oracle.Config{
-		ConfigFilePath: ociConfigFile,
-		Profile:        ociProfile,
-		Namespace:      ociNamespace,
-		BucketName:     ociBucket,
-		CompartmentID:  ociCompartment,
-	}
-
-	client, err := oracle.NewClient(config)
-	if err != nil {
-		return fmt.Errorf("failed to create OCI client: %w", err)
-	}
-
-	fmt.Printf("✓ Connected to namespace: %s\n", client.GetNamespace())
-	fmt.Printf("📥 Downloading object: %s\n", downloadObjectName)
-
 	// Start timing for metrics
 	startTime := time.Now()
 
-	// Download the file
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
-	defer cancel()
+	if err := validateRehydrateFlags(); err != nil {
+		return err
+	}
 
-	result, err := client.DownloadFile(ctx, downloadObjectName, downloadOutput)
+	ctx := context.Background()
+	if downloadTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, downloadTimeout)
+		defer cancel()
+	}
+
+	var result *storage.DownloadResult
+	var err error
+
+	if storage.IsURL(downloadObjectName) {
+		// Shared links need no credentials or backend
+		fmt.Printf("📥 Downloading shared link: %s\n", storage.URLObjectName(downloadObjectName))
+		result, err = storage.DownloadURL(ctx, downloadObjectName, downloadOutput)
+	} else {
+		fmt.Printf("🔗 Connecting to %s storage...\n", resolveBackendName())
+
+		// Create storage backend
+		var backend storage.Backend
+		backend, err = newStorageBackend()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Connected to: %s\n", backend.Location())
+		fmt.Printf("📥 Downloading object: %s\n", downloadObjectName)
+
+		// Archived backups must be rehydrated before they can be read
+		if err := ensureReadable(ctx, backend, downloadObjectName); err != nil {
+			metrics.DownloadFailure.WithLabelValues("rehydrate_failed").Inc()
+			return fmt.Errorf("download failed: %w", err)
+		}
+
+		result, err = storage.DownloadFile(ctx, backend, downloadObjectName, downloadOutput)
+	}
 	if err != nil {
 		// Record failure metrics
-		metrics.DownloadFailure.WithLabelValues("download_failed").Inc()
+		reason := "download_failed"
+		if errors.Is(err, storage.ErrChecksumMismatch) {
+			reason = "checksum_mismatch"
+		}
+		metrics.DownloadFailure.WithLabelValues(reason).Inc()
 		return fmt.Errorf("download failed: %w", err)
 	}
 
@@ -88,6 +121,11 @@ func runDownload(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  Size: %.2f MB\n", float64(result.Size)/1024/1024)
 	fmt.Printf("  Duration: %s\n", result.Duration.Round(time.Millisecond))
 	fmt.Printf("  Last modified: %s\n", result.LastModified.Format(time.RFC3339))
+	if result.Verified != "" {
+		fmt.Printf("  Verified: %s ✓\n", result.Verified)
+	} else {
+		fmt.Printf("  Verified: no (object has no checksum)\n")
+	}
 
 	return nil
 }
@@ -3,28 +3,50 @@ package main
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
-	"github.com/Kobeep/cloud-dr-orchestrator/pkg/oracle"
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/backup"
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
 	"github.com/spf13/cobra"
 )
 
 var listCmd = &cobra.Command{
 	Use:   "list",
-	Short: "List backup files in Oracle Cloud Object Storage",
-	Long: `List all backup files stored in Oracle Cloud Object Storage.
+	Short: "List backup files in cloud storage",
+	Long: `List all backup files stored in the configured storage backend.
 You can optionally filter by year and month.
 
+Results are streamed page by page, so very large buckets are listed completely.
+
+--details also shows each backup's type, database and whether it is encrypted
+(and with which key). This metadata is not served by the list API: it costs one
+HEAD request per object, as do --fields metadata and --filter. These requests
+run concurrently, but listing a large bucket is still much slower than a plain
+listing, so they are only made when asked for.
+
 Examples:
   orchestrator list                          # List all backups
-  orchestrator list --year 2025 --month 12  # List backups from December 2025`,
+  orchestrator list --year 2025 --month 12  # List backups from December 2025
+  orchestrator list --start backups/2024/06/ --end backups/2025/01/
+  orchestrator list --fields md5,storageTier
+  orchestrator list --details --year 2025    # Show type, database and encryption
+  orchestrator list --fields metadata        # Show all metadata of each backup
+  orchestrator list --filter backup-type=postgres --filter database=myapp`,
 	RunE: runList,
 }
 
 var (
-	listYear  int
-	listMonth int
-	listAll   bool
+	listYear    int
+	listMonth   int
+	listAll     bool
+	listStart   string
+	listEnd     string
+	listFields  []string
+	listFilter  map[string]string
+	listTimeout time.Duration
+	listDetails bool
+	listNoMeta  bool
 )
 
 func init() {
@@ -33,74 +55,135 @@ func init() {
 	listCmd.Flags().IntVar(&listYear, "year", 0, "Filter backups by year")
 	listCmd.Flags().IntVar(&listMonth, "month", 0, "Filter backups by month (requires --year)")
 	listCmd.Flags().BoolVar(&listAll, "all", false, "List all objects in bucket (not just backups)")
-	listCmd.Flags().StringVar(&ociConfigFile, "oci-config", "", "Path to OCI config file (default: ~/.oci/config)")
-	listCmd.Flags().StringVar(&ociProfile, "oci-profile", "DEFAULT", "OCI config profile to use")
-	listCmd.Flags().StringVar(&ociBucket, "bucket", "", "OCI Object Storage bucket name (required)")
-	listCmd.Flags().StringVar(&ociNamespace, "namespace", "", "OCI namespace (auto-detected if not provided)")
-	listCmd.Flags().StringVar(&ociCompartment, "compartment", "", "OCI compartment ID (required)")
-
-	listCmd.MarkFlagRequired("bucket")
-	listCmd.MarkFlagRequired("compartment")
+	listCmd.Flags().StringVar(&listStart, "start", "", "Only list objects whose name is >= this value")
+	listCmd.Flags().StringVar(&listEnd, "end", "", "Only list objects whose name is < this value")
+	listCmd.Flags().StringSliceVar(&listFields, "fields", []string{}, "Extra fields to show: md5, storageTier, archivalState, timeCreated, metadata (one HEAD request per object)")
+	listCmd.Flags().StringToStringVar(&listFilter, "filter", map[string]string{}, "Only list backups whose metadata matches key=value, e.g. backup-type=postgres (can be specified multiple times, one HEAD request per object)")
+	listCmd.Flags().BoolVar(&listDetails, "details", false, "Show each backup's type, database and encryption key (one HEAD request per object)")
+	listCmd.Flags().BoolVar(&listNoMeta, "no-metadata", false, "Do not fetch backup metadata")
+	listCmd.Flags().MarkDeprecated("no-metadata", "metadata is only fetched with --details, --fields metadata or --filter")
+	listCmd.Flags().DurationVar(&listTimeout, "timeout", 0, "Abort the listing after this duration, e.g. 10m (default: no timeout)")
+	addStorageFlags(listCmd)
 }
 
 func runList(cmd *cobra.Command, args []string) error {
-	fmt.Printf("🔗 Connecting to Oracle Cloud...\n")
-
-	// Create OCI client
-	config := oracle.Config{
-		ConfigFilePath: ociConfigFile,
-		Profile:        ociProfile,
-		Namespace:      ociNamespace,
-		BucketName:     ociBucket,
-		CompartmentID:  ociCompartment,
-	}
+	fmt.Printf("🔗 Connecting to %s storage...\n", resolveBackendName())
 
-	client, err := oracle.NewClient(config)
+	// Create storage backend
+	backend, err := newStorageBackend()
 	if err != nil {
-		return fmt.Errorf("failed to create OCI client: %w", err)
+		return err
 	}
 
-	fmt.Printf("✓ Connected to namespace: %s\n", client.GetNamespace())
-	fmt.Printf("📋 Listing backups from bucket: %s\n\n", client.GetBucketName())
+	fmt.Printf("📋 Listing backups from: %s\n\n", backend.Location())
 
 	// List objects
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
-	defer cancel()
+	ctx := context.Background()
+	if listTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, listTimeout)
+		defer cancel()
+	}
 
-	var objects []oracle.ObjectInfo
+	prefix := storage.BackupsPrefix
 	if listAll {
-		objects, err = client.ListObjects(ctx, "")
-	} else if listYear > 0 && listMonth > 0 {
-		objects, err = client.ListBackupsByDate(ctx, listYear, listMonth)
+		prefix = ""
 	} else if listYear > 0 {
-		prefix := fmt.Sprintf("backups/%d/", listYear)
-		objects, err = client.ListObjects(ctx, prefix)
-	} else {
-		objects, err = client.ListBackups(ctx)
+		prefix = storage.BackupDatePrefix(listYear, listMonth)
 	}
 
-	if err != nil {
-		return fmt.Errorf("failed to list objects: %w", err)
+	opts := storage.ListOptions{
+		Prefix:   prefix,
+		Start:    listStart,
+		End:      listEnd,
+		Fields:   listFields,
+		Metadata: listFilter,
 	}
 
-	// Display results
-	if len(objects) == 0 {
-		fmt.Println("No backups found.")
-		return nil
+	if listNoMeta && (listDetails || len(listFilter) > 0 || opts.HasField(storage.FieldMetadata)) {
+		return fmt.Errorf("--no-metadata cannot be combined with --details, --filter or --fields metadata")
 	}
 
-	fmt.Printf("Found %d backup(s):\n\n", len(objects))
+	// Show the metadata that is being filtered on. A plain listing makes no
+	// HEAD requests, so buckets with years of backups stay fast to list.
+	allMeta := len(listFilter) > 0 || opts.HasField(storage.FieldMetadata)
+	if listDetails && !opts.HasField(storage.FieldMetadata) {
+		opts.Fields = append(opts.Fields, storage.FieldMetadata)
+	}
 
+	// Display results as they are fetched
+	var count int
 	var totalSize int64
-	for i, obj := range objects {
-		fmt.Printf("%d. %s\n", i+1, obj.Name)
-		fmt.Printf("   Size: %.2f MB\n", float64(obj.Size)/1024/1024)
-		fmt.Printf("   Modified: %s\n", obj.LastModified.Format("2006-01-02 15:04:05"))
-		fmt.Printf("   ETag: %s\n\n", obj.ETag)
+	err = storage.Walk(ctx, backend, opts, func(obj storage.ObjectInfo) error {
+		count++
 		totalSize += obj.Size
+		printObject(count, obj, opts, allMeta)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list objects: %w", err)
 	}
 
-	fmt.Printf("Total: %d file(s), %.2f MB\n", len(objects), float64(totalSize)/1024/1024)
+	if count == 0 {
+		fmt.Println("No backups found.")
+		return nil
+	}
+
+	fmt.Printf("Total: %d file(s), %.2f MB\n", count, float64(totalSize)/1024/1024)
 
 	return nil
 }
+
+// printObject prints a single listing entry including any requested fields.
+// Unless allMeta is set, only the core backup metadata is shown.
+func printObject(index int, obj storage.ObjectInfo, opts storage.ListOptions, allMeta bool) {
+	fmt.Printf("%d. %s\n", index, obj.Name)
+	fmt.Printf("   Size: %.2f MB\n", float64(obj.Size)/1024/1024)
+	fmt.Printf("   Modified: %s\n", obj.LastModified.Format("2006-01-02 15:04:05"))
+	fmt.Printf("   ETag: %s\n", obj.ETag)
+	if opts.HasField(storage.FieldMD5) && obj.MD5 != "" {
+		fmt.Printf("   MD5: %s\n", obj.MD5)
+	}
+	if opts.HasField(storage.FieldStorageTier) && obj.StorageTier != "" {
+		fmt.Printf("   Storage tier: %s\n", obj.StorageTier)
+	}
+	if opts.HasField(storage.FieldArchivalState) && obj.ArchivalState != "" {
+		fmt.Printf("   Archival state: %s\n", obj.ArchivalState)
+	}
+	if opts.HasField(storage.FieldTimeCreated) && !obj.TimeCreated.IsZero() {
+		fmt.Printf("   Created: %s\n", obj.TimeCreated.Format("2006-01-02 15:04:05"))
+	}
+	if allMeta {
+		keys := make([]string, 0, len(obj.Metadata))
+		for key := range obj.Metadata {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Printf("   %s: %s\n", key, obj.Metadata[key])
+		}
+	} else if opts.HasField(storage.FieldMetadata) {
+		printCoreMetadata(obj.Metadata)
+	}
+	fmt.Println()
+}
+
+// printCoreMetadata prints what a backup is and how it is encrypted
+func printCoreMetadata(meta map[string]string) {
+	if backupType := meta[backup.MetaType]; backupType != "" {
+		fmt.Printf("   Type: %s\n", backupType)
+	}
+	if database := meta[backup.MetaDatabase]; database != "" {
+		fmt.Printf("   Database: %s\n", database)
+	}
+	switch {
+	case meta[backup.MetaEncryptionKeyID] != "":
+		fmt.Printf("   Encrypted: yes (key %s)\n", meta[backup.MetaEncryptionKeyID])
+	case meta[backup.MetaEncrypted] != "":
+		encrypted := "no"
+		if meta[backup.MetaEncrypted] == "true" {
+			encrypted = "yes"
+		}
+		fmt.Printf("   Encrypted: %s\n", encrypted)
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// version is set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
 var rootCmd = &cobra.Command{
 	Use:   "orchestrator",
 	Short: "Cloud DR Orchestrator - Backup and restore tool for Oracle Cloud",
@@ -15,6 +18,7 @@ and files, storing them securely in Oracle Cloud Object Storage.`,
 }
 
 func main() {
+	rootCmd.Version = version
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
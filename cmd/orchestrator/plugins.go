@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/backup"
+	"github.com/spf13/cobra"
+)
+
+var pluginsCmd = &cobra.Command{
+	Use:   "plugins",
+	Short: "List backup type plugins found on PATH",
+	Long: `List the backup type plugins found on PATH.
+
+An executable named orchestrator-backup-<type> adds <type> to the types
+accepted by "backup --type" and "restore --type". Plugin options are passed
+with --plugin-opt key=value. See docs/PLUGINS.md for the protocol.
+
+Examples:
+  orchestrator plugins
+  orchestrator backup --type ldap --name directory --plugin-opt suffix=dc=example,dc=com`,
+	RunE: runPlugins,
+}
+
+func init() {
+	rootCmd.AddCommand(pluginsCmd)
+}
+
+func runPlugins(cmd *cobra.Command, args []string) error {
+	plugins := backup.DiscoverPlugins()
+	if len(plugins) == 0 {
+		fmt.Printf("No plugins found (looking for %s<type> on PATH)\n", backup.PluginPrefix)
+		return nil
+	}
+
+	fmt.Printf("🔌 Found %d plugin(s):\n\n", len(plugins))
+	for _, plugin := range plugins {
+		fmt.Printf("  %s - %s\n", plugin.Type, plugin.Description)
+		fmt.Printf("     Path: %s\n", plugin.Path)
+		fmt.Printf("     Supports: %s\n", strings.Join(plugin.Capabilities, ", "))
+		for _, option := range plugin.Options {
+			required := ""
+			if option.Required {
+				required = " (required)"
+			}
+			fmt.Printf("     --plugin-opt %s=...%s  %s\n", option.Name, required, option.Description)
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
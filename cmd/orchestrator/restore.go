@@ -3,6 +3,9 @@ package main
 import (
 	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -12,15 +15,17 @@ import (
 	"github.com/Kobeep/cloud-dr-orchestrator/pkg/backup"
 	"github.com/Kobeep/cloud-dr-orchestrator/pkg/encryption"
 	"github.com/Kobeep/cloud-dr-orchestrator/pkg/metrics"
-	"github.com/Kobeep/cloud-dr-orchestrator/pkg/oracle"
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
 	"github.com/spf13/cobra"
 )
 
 var restoreCmd = &cobra.Command{
 	Use:   "restore",
-	Short: "Restore PostgreSQL database from backup",
-	Long: `Restore a PostgreSQL database from a local .tar.gz backup file or
-download from Oracle Cloud Object Storage and restore.
+	Short: "Restore a database from backup",
+	Long: `Restore a PostgreSQL (logical or physical), MySQL/MariaDB, MongoDB, Redis or
+SQLite database, Kubernetes resources, Git repositories or the payload of an
+exec backup, from a local backup file or download from the configured storage
+backend and restore.
 
 Examples:
   # Restore from local backup file
@@ -29,6 +34,58 @@ Examples:
   # Download from cloud and restore
   orchestrator restore --from-cloud backups/2025/12/backup-20251209.tar.gz --bucket my-bucket --compartment ocid1... --db-name mydb --db-host localhost --db-user postgres --db-password secret
 
+  # Restore from a URL created with "orchestrator share create" (no credentials needed)
+  orchestrator restore --from-cloud 'https://objectstorage.../p/.../o/backups/2025/12/backup-20251209.tar.gz' --db-name mydb --db-host localhost --db-user postgres --db-password secret
+
+  # Restore a custom or directory format PostgreSQL backup with 8 parallel workers
+  orchestrator restore --file warehouse.tar.gz --db-name dw --jobs 8
+
+  # Lay a postgres-physical base backup out into an empty data directory
+  orchestrator restore --type postgres-physical --file pg-cluster.tar.gz --pg-data-dir /var/lib/postgresql/16/main
+
+  # Restore one database, or everything with roles and tablespaces, from a PostgreSQL server backup
+  orchestrator restore --file pg-01.tar.gz --db-name shop --target-db shop_scratch
+  orchestrator restore --file pg-01.tar.gz --all-databases
+
+  # Pull two tables out of a custom format backup into a scratch database
+  orchestrator restore --file shop-custom.tar.gz --db-name shop --target-db shop_scratch --table billing.invoices --table billing.payments
+
+  # Point-in-time recovery: lay out a base backup and replay WAL archived with wal-push
+  orchestrator restore --type postgres-physical --file base.tar.gz --pitr --target-time "2025-12-09 14:30:00" --pg-data-dir /var/lib/postgresql/16/main --wal-name main --backend s3 --bucket dr-backups
+
+  # Restore a MySQL/MariaDB backup (created with backup --type mysql)
+  orchestrator restore --type mysql --file shop-db.tar.gz --db-name shop --db-user root --db-password secret
+
+  # Restore an --all-databases MySQL backup; every database in the dump is recreated
+  orchestrator restore --type mysql --file mariadb-01.tar.gz --db-user root --db-password secret
+
+  # Restore a whole MongoDB deployment over existing data, replaying the captured oplog
+  orchestrator restore --type mongodb --file mongo-rs0.archive.gz --mongo-uri "mongodb://..." --drop --oplog-replay
+
+  # Restore one MongoDB database under a new name
+  orchestrator restore --type mongodb --file mongo-rs0.archive.gz --mongo-uri "mongodb://..." --db-name app --target-db app_restored
+
+  # Install a Redis snapshot into a stopped instance's data directory
+  orchestrator restore --type redis --file sessions.tar.gz --redis-data-dir /var/lib/redis
+
+  # Load a Redis snapshot into a running local instance by restarting it
+  orchestrator restore --type redis --file sessions.tar.gz --db-password secret --restart-command "systemctl restart redis"
+
+  # Atomically replace a SQLite database (stop the application first)
+  orchestrator restore --type sqlite --file grafana.tar.gz --db-file /var/lib/grafana/grafana.db
+
+  # Re-apply exported Kubernetes resources of one namespace to the current cluster
+  orchestrator restore --type kubernetes --file k8s-apps.tar.gz --k8s-namespace app-shop
+
+  # Restore Git bundles as bare mirror repositories under /srv/git
+  orchestrator restore --type git --file repos.tar.gz --repo-dir /srv/git
+
+  # Feed an exec backup to a restore command on stdin
+  orchestrator restore --type exec --file ldap.gz --exec-command "slapadd -n 1"
+
+  # Restore through a plugin (see "orchestrator plugins")
+  orchestrator restore --type ldap --file directory.gz --plugin-opt suffix=dc=example,dc=com
+
   # Restore to different target database
   orchestrator restore --file backup.tar.gz --db-name mydb --target-db mydb_restored --db-host localhost --db-user postgres --db-password secret
 `,
@@ -36,43 +93,108 @@ Examples:
 }
 
 var (
+	restoreType          string
 	restoreFile          string
 	restoreFromCloud     string
+	restoreDownloadDir   string
 	restoreTargetDB      string
 	restoreDBName        string
 	restoreDBHost        string
 	restoreDBPort        int
 	restoreDBUser        string
 	restoreDBPassword    string
-	restoreBucket        string
-	restoreCompartment   string
-	restoreOCIConfig     string
-	restoreOCIProfile    string
+	restorePgJobs        int
+	restorePITR          bool
+	restoreAllDatabases  bool
+	restorePgSchemas     []string
+	restorePgTables      []string
+	restorePgExclude     []string
+	restoreTargetTime    string
+	restorePgDataDir     string
+	restoreWALName       string
+	restoreCommand       string
 	restoreSkipConfirm   bool
 	restoreDecrypt       bool
 	restoreDecryptionKey string
+	restoreMongoURI      string
+	restoreOplogReplay   bool
+	restoreMongoDrop     bool
+	restoreRedisDataDir  string
+	restoreRedisRDBFile  string
+	restoreRedisRestart  string
+	restoreDBFile        string
+	restoreKubeconfig    string
+	restoreKubeContext   string
+	restoreK8sNamespaces []string
+	restoreK8sKinds      []string
+	restoreRepoDir       string
+	restoreRepos         []string
+	restoreExecCommand   string
+	restorePluginOptions map[string]string
 )
 
 func init() {
 	rootCmd.AddCommand(restoreCmd)
 
+	restoreCmd.Flags().StringVar(&restoreType, "type", "postgres", "Backup type: postgres, postgres-physical, mysql, mongodb, redis, sqlite, kubernetes, git, exec, or a plugin type")
+
 	// Backup file flags
 	restoreCmd.Flags().StringVar(&restoreFile, "file", "", "Local backup file path (.tar.gz)")
-	restoreCmd.Flags().StringVar(&restoreFromCloud, "from-cloud", "", "Download backup from cloud (object path in bucket)")
+	restoreCmd.Flags().StringVar(&restoreFromCloud, "from-cloud", "", "Download backup from storage backend (object path in bucket or shared HTTPS URL)")
+	restoreCmd.Flags().StringVar(&restoreDownloadDir, "download-dir", "", "Directory for backups downloaded with --from-cloud; an interrupted download resumes from it when the restore is re-run (default: <tmp>/orchestrator-restore)")
 
 	// Database connection flags
-	restoreCmd.Flags().StringVar(&restoreDBName, "db-name", "", "Database name to restore to (required)")
+	restoreCmd.Flags().StringVar(&restoreDBName, "db-name", "", "Database name to restore to (required for postgres)")
 	restoreCmd.Flags().StringVar(&restoreTargetDB, "target-db", "", "Target database name (if different from source)")
 	restoreCmd.Flags().StringVar(&restoreDBHost, "db-host", "localhost", "Database host")
-	restoreCmd.Flags().IntVar(&restoreDBPort, "db-port", 5432, "Database port")
-	restoreCmd.Flags().StringVar(&restoreDBUser, "db-user", "postgres", "Database user")
+	restoreCmd.Flags().IntVar(&restoreDBPort, "db-port", 5432, "Database port (default 3306 for mysql, 6379 for redis)")
+	restoreCmd.Flags().StringVar(&restoreDBUser, "db-user", "postgres", "Database user (default root for mysql)")
 	restoreCmd.Flags().StringVar(&restoreDBPassword, "db-password", "", "Database password")
-
-	// Oracle Cloud flags (only needed if --from-cloud is used)
-	restoreCmd.Flags().StringVar(&restoreBucket, "bucket", "", "OCI Object Storage bucket name")
-	restoreCmd.Flags().StringVar(&restoreCompartment, "compartment", "", "OCI compartment OCID")
-	restoreCmd.Flags().StringVar(&restoreOCIConfig, "oci-config", "", "OCI config file path (default: ~/.oci/config)")
-	restoreCmd.Flags().StringVar(&restoreOCIProfile, "oci-profile", "DEFAULT", "OCI config profile")
+	restoreCmd.Flags().BoolVar(&restoreAllDatabases, "all-databases", false, "Restore roles, tablespaces and every database of a PostgreSQL server backup")
+	restoreCmd.Flags().IntVar(&restorePgJobs, "jobs", 1, "Parallel pg_restore workers for custom and directory format PostgreSQL backups")
+	restoreCmd.Flags().StringSliceVar(&restorePgSchemas, "schema", []string{}, "Only restore these PostgreSQL schemas, glob patterns allowed (custom and directory format backups; plain dumps are restored whole)")
+	restoreCmd.Flags().StringSliceVar(&restorePgTables, "table", []string{}, "Only restore these PostgreSQL tables (table or schema.table), glob patterns allowed (custom and directory format backups; plain dumps are restored whole)")
+	restoreCmd.Flags().StringSliceVar(&restorePgExclude, "exclude-table", []string{}, "PostgreSQL tables to leave out along with everything depending on them (custom and directory format backups; plain dumps are restored whole)")
+
+	// PostgreSQL point-in-time recovery flags
+	restoreCmd.Flags().BoolVar(&restorePITR, "pitr", false, "Point-in-time recovery: lay out a base backup and replay archived WAL")
+	restoreCmd.Flags().StringVar(&restoreTargetTime, "target-time", "", "Recover up to this time, RFC 3339 or \"YYYY-MM-DD HH:MM:SS\" local time (default: end of archived WAL)")
+	restoreCmd.Flags().StringVar(&restorePgDataDir, "pg-data-dir", "", "Empty PostgreSQL data directory to recover into (required with --pitr)")
+	restoreCmd.Flags().StringVar(&restoreWALName, "wal-name", "", "Cluster name the WAL was archived under with wal-push --name")
+	restoreCmd.Flags().StringVar(&restoreCommand, "restore-command", "", "restore_command to use instead of wal-fetch with the storage flags")
+
+	// MongoDB flags
+	restoreCmd.Flags().StringVar(&restoreMongoURI, "mongo-uri", "", "MongoDB connection URI (or use MONGODB_URI env var)")
+	restoreCmd.Flags().BoolVar(&restoreOplogReplay, "oplog-replay", false, "Replay the oplog captured with a whole-deployment MongoDB backup")
+	restoreCmd.Flags().BoolVar(&restoreMongoDrop, "drop", false, "Drop each MongoDB collection before restoring it (existing documents are kept by default)")
+
+	// Redis flags
+	restoreCmd.Flags().StringVar(&restoreRedisDataDir, "redis-data-dir", "", "Data directory of the Redis instance to restore into (asked from the instance with --restart-command)")
+	restoreCmd.Flags().StringVar(&restoreRedisRDBFile, "redis-rdb-filename", "", "dbfilename of the Redis instance (default dump.rdb, or asked from the instance with --restart-command)")
+	restoreCmd.Flags().StringVar(&restoreRedisRestart, "restart-command", "", "Shell command that restarts a running Redis instance so it loads the snapshot, e.g. \"systemctl restart redis\"")
+
+	// SQLite flags
+	restoreCmd.Flags().StringVar(&restoreDBFile, "db-file", "", "SQLite database file to replace")
+
+	// Kubernetes flags
+	restoreCmd.Flags().StringVar(&restoreKubeconfig, "kubeconfig", "", "kubeconfig file (default: kubectl's)")
+	restoreCmd.Flags().StringVar(&restoreKubeContext, "kube-context", "", "kubeconfig context (default: current context)")
+	restoreCmd.Flags().StringSliceVar(&restoreK8sNamespaces, "k8s-namespace", []string{}, "Only restore these namespaces, glob patterns allowed (default: all)")
+	restoreCmd.Flags().StringSliceVar(&restoreK8sKinds, "k8s-kind", []string{}, "Only restore these resources, e.g. deployments (default: all)")
+
+	// Git flags
+	restoreCmd.Flags().StringVar(&restoreRepoDir, "repo-dir", "", "Directory to restore Git repositories into as <name>.git")
+	restoreCmd.Flags().StringSliceVar(&restoreRepos, "repo", []string{}, "Only restore these repositories (default: all)")
+
+	// Exec flags
+	restoreCmd.Flags().StringVar(&restoreExecCommand, "exec-command", "", "Shell command that receives the backup payload on stdin")
+
+	// Plugin flags
+	restoreCmd.Flags().StringToStringVar(&restorePluginOptions, "plugin-opt", map[string]string{}, "Option for a plugin backup type as key=value (can be specified multiple times)")
+
+	// Storage flags (only needed if --from-cloud is used)
+	addStorageFlags(restoreCmd)
+	addRehydrateFlags(restoreCmd)
 
 	// Safety flag
 	restoreCmd.Flags().BoolVar(&restoreSkipConfirm, "yes", false, "Skip confirmation prompt")
@@ -80,12 +202,11 @@ func init() {
 	// Decryption flags
 	restoreCmd.Flags().BoolVar(&restoreDecrypt, "decrypt", false, "Decrypt backup file (auto-detected for .encrypted files)")
 	restoreCmd.Flags().StringVar(&restoreDecryptionKey, "decryption-key", "", "Decryption key (or use BACKUP_ENCRYPTION_KEY env var)")
-
-	// Required flags
-	restoreCmd.MarkFlagRequired("db-name")
 }
 
 func runRestore(cmd *cobra.Command, args []string) error {
+	var restorePlugin *backup.Plugin
+
 	// Validate flags
 	if restoreFile == "" && restoreFromCloud == "" {
 		return fmt.Errorf("either --file or --from-cloud must be specified")
@@ -93,11 +214,88 @@ func runRestore(cmd *cobra.Command, args []string) error {
 	if restoreFile != "" && restoreFromCloud != "" {
 		return fmt.Errorf("cannot specify both --file and --from-cloud")
 	}
-
-	// If downloading from cloud, validate cloud flags
-	if restoreFromCloud != "" {
-		if restoreBucket == "" || restoreCompartment == "" {
-			return fmt.Errorf("--bucket and --compartment are required when using --from-cloud")
+	if err := validateRehydrateFlags(); err != nil {
+		return err
+	}
+	var targetTime time.Time
+	if restorePITR {
+		// Only a base backup can be laid out as a data directory
+		if restoreType != "postgres-physical" {
+			return fmt.Errorf("--pitr is only supported for postgres-physical restores (a base backup)")
+		}
+		if restorePgDataDir == "" {
+			return fmt.Errorf("--pg-data-dir is required for --pitr")
+		}
+		if restoreWALName == "" && restoreCommand == "" {
+			return fmt.Errorf("--wal-name or --restore-command is required for --pitr")
+		}
+		if restoreTargetTime != "" {
+			var err error
+			if targetTime, err = parseTargetTime(restoreTargetTime); err != nil {
+				return err
+			}
+		}
+	}
+	switch restoreType {
+	case "postgres":
+		if restoreAllDatabases && restoreDBName != "" {
+			return fmt.Errorf("cannot combine --db-name with --all-databases")
+		}
+		if !restoreAllDatabases && restoreDBName == "" {
+			return fmt.Errorf("--db-name or --all-databases is required for postgres restore")
+		}
+		if len(restorePgSchemas) > 0 && len(restorePgTables) > 0 {
+			return fmt.Errorf("cannot combine --schema with --table; qualify the tables with their schema instead")
+		}
+		if restoreAllDatabases && len(restorePgSchemas)+len(restorePgTables)+len(restorePgExclude) > 0 {
+			return fmt.Errorf("--schema, --table and --exclude-table need a single database to restore")
+		}
+	case "postgres-physical":
+		if restorePgDataDir == "" {
+			return fmt.Errorf("--pg-data-dir is required for postgres-physical restore")
+		}
+	case "mysql":
+		// The connection defaults are PostgreSQL's
+		if !cmd.Flags().Changed("db-port") {
+			restoreDBPort = 3306
+		}
+		if !cmd.Flags().Changed("db-user") {
+			restoreDBUser = "root"
+		}
+	case "mongodb":
+		if restoreMongoURI == "" {
+			restoreMongoURI = os.Getenv("MONGODB_URI")
+		}
+		if restoreMongoURI == "" {
+			return fmt.Errorf("--mongo-uri (or MONGODB_URI env var) is required for mongodb restore")
+		}
+	case "redis":
+		if restoreRedisDataDir == "" && restoreRedisRestart == "" {
+			return fmt.Errorf("--redis-data-dir is required for redis restore without --restart-command")
+		}
+		if !cmd.Flags().Changed("db-port") {
+			restoreDBPort = 6379
+		}
+	case "sqlite":
+		if restoreDBFile == "" {
+			return fmt.Errorf("--db-file is required for sqlite restore")
+		}
+	case "kubernetes":
+	case "git":
+		if restoreRepoDir == "" {
+			return fmt.Errorf("--repo-dir is required for git restore")
+		}
+	case "exec":
+		if restoreExecCommand == "" {
+			return fmt.Errorf("--exec-command is required for exec restore")
+		}
+	default:
+		var err error
+		if restorePlugin, err = loadPlugin(restoreType); err != nil {
+			return err
+		}
+		if !restorePlugin.Supports(backup.PluginCommandRestore) {
+			return fmt.Errorf("plugin %s does not support restores", restoreType)
 		}
 	}
 
@@ -115,40 +313,61 @@ func runRestore(cmd *cobra.Command, args []string) error {
 	var cleanupFile bool
 
 	if restoreFromCloud != "" {
-		// Download from Oracle Cloud
-		fmt.Printf("📥 Downloading backup from Oracle Cloud...\n")
-		fmt.Printf("   Bucket: %s\n", restoreBucket)
-		fmt.Printf("   Object: %s\n", restoreFromCloud)
-
-		// Initialize Oracle Cloud client
-		config := oracle.Config{
-			ConfigFilePath: restoreOCIConfig,
-			Profile:        restoreOCIProfile,
-			BucketName:     restoreBucket,
-			CompartmentID:  restoreCompartment,
+		// The download goes to a stable path so the partial file and resume
+		// state of an interrupted download are found again on the next run
+		downloadDir := restoreDownloadDir
+		if downloadDir == "" {
+			downloadDir = filepath.Join(os.TempDir(), "orchestrator-restore")
 		}
-
-		client, err := oracle.NewClient(config)
-		if err != nil {
-			return fmt.Errorf("failed to initialize Oracle Cloud client: %w", err)
+		if err := os.MkdirAll(downloadDir, 0700); err != nil {
+			return fmt.Errorf("failed to create download directory: %w", err)
 		}
 
-		// Create temporary directory
-		tempDir, err := os.MkdirTemp("", "orchestrator-restore-*")
-		if err != nil {
-			return fmt.Errorf("failed to create temp directory: %w", err)
-		}
-		defer os.RemoveAll(tempDir)
-
-		// Download file
-		backupFilePath = filepath.Join(tempDir, filepath.Base(restoreFromCloud))
 		ctx := context.Background()
-		_, err = client.DownloadFile(ctx, restoreFromCloud, backupFilePath)
+		var result *storage.DownloadResult
+		var err error
+
+		if storage.IsURL(restoreFromCloud) {
+			// Shared links need no credentials or backend
+			fmt.Printf("📥 Downloading backup from shared link...\n")
+			fmt.Printf("   Object: %s\n", storage.URLObjectName(restoreFromCloud))
+
+			backupFilePath = restoreDownloadPath(downloadDir, restoreFromCloud, storage.URLObjectName(restoreFromCloud))
+			result, err = storage.DownloadURL(ctx, restoreFromCloud, backupFilePath)
+		} else {
+			// Initialize storage backend
+			var backend storage.Backend
+			backend, err = newStorageBackend()
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage backend: %w", err)
+			}
+
+			// Download from storage
+			fmt.Printf("📥 Downloading backup from %s storage...\n", resolveBackendName())
+			fmt.Printf("   Location: %s\n", backend.Location())
+			fmt.Printf("   Object: %s\n", restoreFromCloud)
+
+			// Download file
+			backupFilePath = restoreDownloadPath(downloadDir, backend.Location()+"/"+restoreFromCloud, restoreFromCloud)
+			if err := ensureReadable(ctx, backend, restoreFromCloud); err != nil {
+				return fmt.Errorf("failed to rehydrate backup: %w", err)
+			}
+			result, err = storage.DownloadFile(ctx, backend, restoreFromCloud, backupFilePath)
+		}
 		if err != nil {
+			if errors.Is(err, storage.ErrChecksumMismatch) {
+				metrics.RestoreFailure.WithLabelValues("checksum_mismatch").Inc()
+			}
 			return fmt.Errorf("failed to download backup: %w", err)
 		}
-		cleanupFile = true
-		fmt.Printf("✅ Downloaded to: %s\n\n", backupFilePath)
+		// Verified and complete: the download is not needed after this run
+		defer os.Remove(backupFilePath)
+		fmt.Printf("✅ Downloaded to: %s\n", backupFilePath)
+		if result.Verified != "" {
+			fmt.Printf("✅ Integrity verified (%s)\n\n", result.Verified)
+		} else {
+			fmt.Printf("⚠️  Integrity not verified (object has no checksum)\n\n")
+		}
 	} else {
 		// Use local file
 		backupFilePath = restoreFile
@@ -186,17 +405,91 @@ func runRestore(cmd *cobra.Command, args []string) error {
 
 	// Show restore plan
 	fmt.Printf("🔄 Restore Plan:\n")
+	fmt.Printf("   Backup type: %s\n", restoreType)
 	fmt.Printf("   Backup file: %s\n", backupFilePath)
-	fmt.Printf("   Target host: %s:%d\n", pgConfig.Host, pgConfig.Port)
-	fmt.Printf("   Target database: %s\n", pgConfig.Database)
+	switch restoreType {
+	case "mongodb":
+	case "redis":
+		if restoreRedisDataDir != "" {
+			fmt.Printf("   Target data directory: %s\n", restoreRedisDataDir)
+		} else {
+			fmt.Printf("   Target data directory: (asked from %s:%d)\n", restoreDBHost, restoreDBPort)
+		}
+		if restoreRedisRestart != "" {
+			fmt.Printf("   Restart command: %s\n", restoreRedisRestart)
+		}
+	case "sqlite":
+		fmt.Printf("   Target file: %s\n", restoreDBFile)
+	case "kubernetes":
+		if restoreKubeContext != "" {
+			fmt.Printf("   Target context: %s\n", restoreKubeContext)
+		} else {
+			fmt.Printf("   Target context: (current)\n")
+		}
+		if len(restoreK8sNamespaces) > 0 {
+			fmt.Printf("   Namespaces: %s\n", strings.Join(restoreK8sNamespaces, ", "))
+		}
+	case "git":
+		fmt.Printf("   Target directory: %s\n", restoreRepoDir)
+	case "exec":
+		fmt.Printf("   Restore command: %s\n", restoreExecCommand)
+	case "postgres", "postgres-physical", "mysql":
+		if restoreType == "postgres-physical" {
+			fmt.Printf("   Target data directory: %s\n", restorePgDataDir)
+		} else {
+			fmt.Printf("   Target host: %s:%d\n", pgConfig.Host, pgConfig.Port)
+		}
+		switch {
+		case !restorePITR:
+		case targetTime.IsZero():
+			fmt.Printf("   Recovery target: end of archived WAL\n")
+		default:
+			fmt.Printf("   Recovery target: %s\n", targetTime.Format(time.RFC3339))
+		}
+	default:
+		fmt.Printf("   Plugin: %s\n", restorePlugin.Path)
+	}
+	switch {
+	case restoreType == "kubernetes", restoreType == "git", restoreType == "exec", restorePlugin != nil, restoreType == "postgres-physical":
+	case pgConfig.Database != "":
+		fmt.Printf("   Target database: %s\n", pgConfig.Database)
+	default:
+		fmt.Printf("   Target database: all databases in the backup\n")
+	}
 	if restoreTargetDB != "" {
 		fmt.Printf("   Will restore as: %s\n", restoreTargetDB)
 	}
+	if len(restorePgSchemas) > 0 {
+		fmt.Printf("   Schemas: %s\n", strings.Join(restorePgSchemas, ", "))
+	}
+	if len(restorePgTables) > 0 {
+		fmt.Printf("   Tables: %s\n", strings.Join(restorePgTables, ", "))
+	}
+	if len(restorePgExclude) > 0 {
+		fmt.Printf("   Excluded tables: %s\n", strings.Join(restorePgExclude, ", "))
+	}
 	fmt.Printf("\n")
 
 	// Confirmation prompt
 	if !restoreSkipConfirm {
-		fmt.Printf("⚠️  WARNING: This will overwrite the database '%s'!\n", pgConfig.Database)
+		switch {
+		case restoreType == "kubernetes":
+			fmt.Printf("⚠️  WARNING: This will apply the backed up resources over the existing ones in the cluster!\n")
+		case restoreType == "git":
+			fmt.Printf("⚠️  This will create the backed up repositories in %s (existing ones are not touched)\n", restoreRepoDir)
+		case restoreType == "exec":
+			fmt.Printf("⚠️  WARNING: This will run the restore command with the backup on its input!\n")
+		case restorePlugin != nil:
+			fmt.Printf("⚠️  WARNING: This will run the %s plugin's restore, which may overwrite existing data!\n", restoreType)
+		case restorePITR:
+			fmt.Printf("⚠️  This will set up %s to recover from the base backup and archived WAL\n", restorePgDataDir)
+		case restoreType == "postgres-physical":
+			fmt.Printf("⚠️  This will lay the base backup out in %s (it must be empty)\n", restorePgDataDir)
+		case pgConfig.Database != "":
+			fmt.Printf("⚠️  WARNING: This will overwrite the database '%s'!\n", pgConfig.Database)
+		default:
+			fmt.Printf("⚠️  WARNING: This will overwrite every database in the backup!\n")
+		}
 		fmt.Printf("Are you sure you want to continue? (yes/no): ")
 
 		reader := bufio.NewReader(os.Stdin)
@@ -217,7 +510,82 @@ func runRestore(cmd *cobra.Command, args []string) error {
 	startTime := time.Now()
 
 	// Perform restore
-	if err := backup.RestorePostgres(pgConfig, backupFilePath, restoreTargetDB); err != nil {
+	var err error
+	switch restoreType {
+	case "mysql":
+		mysqlConfig := backup.MySQLConfig{
+			Host:     pgConfig.Host,
+			Port:     pgConfig.Port,
+			User:     pgConfig.User,
+			Password: pgConfig.Password,
+			Database: pgConfig.Database,
+		}
+		err = backup.RestoreMySQL(mysqlConfig, backupFilePath, restoreTargetDB)
+	case "redis":
+		err = backup.RestoreRedis(backupFilePath, backup.RedisRestoreOptions{
+			DataDir:     restoreRedisDataDir,
+			RDBFilename: restoreRedisRDBFile,
+			Instance: &backup.RedisConfig{
+				Host:     pgConfig.Host,
+				Port:     pgConfig.Port,
+				Password: pgConfig.Password,
+			},
+			RestartCommand: restoreRedisRestart,
+		})
+	case "sqlite":
+		err = backup.RestoreSQLite(backupFilePath, restoreDBFile)
+	case "exec":
+		err = backup.RestoreExec(backupFilePath, restoreExecCommand)
+	case "git":
+		err = backup.RestoreGit(backupFilePath, restoreRepoDir, backup.GitRestoreOptions{
+			Repositories: restoreRepos,
+		})
+	case "kubernetes":
+		err = backup.RestoreKubernetes(backup.KubernetesConfig{
+			Kubeconfig: restoreKubeconfig,
+			Context:    restoreKubeContext,
+		}, backupFilePath, backup.KubernetesRestoreOptions{
+			Namespaces: restoreK8sNamespaces,
+			Kinds:      restoreK8sKinds,
+		})
+	case "mongodb":
+		err = backup.RestoreMongo(backup.MongoConfig{URI: restoreMongoURI}, backupFilePath, backup.MongoRestoreOptions{
+			SourceDB:    restoreDBName,
+			TargetDB:    restoreTargetDB,
+			Drop:        restoreMongoDrop,
+			OplogReplay: restoreOplogReplay,
+		})
+	case "postgres", "postgres-physical":
+		if restorePITR {
+			command := restoreCommand
+			if command == "" {
+				if command, err = walFetchRestoreCommand(cmd, restoreWALName); err != nil {
+					break
+				}
+			}
+			err = backup.RestorePostgresPITR(backupFilePath, backup.PostgresPITROptions{
+				DataDir:        restorePgDataDir,
+				TargetTime:     targetTime,
+				RestoreCommand: command,
+			})
+			break
+		}
+		if restoreType == "postgres-physical" {
+			err = backup.RestorePostgresPhysical(backupFilePath, restorePgDataDir)
+			break
+		}
+		err = backup.RestorePostgres(pgConfig, backupFilePath, backup.PostgresRestoreOptions{
+			TargetDB:      restoreTargetDB,
+			Jobs:          restorePgJobs,
+			AllDatabases:  restoreAllDatabases,
+			Schemas:       restorePgSchemas,
+			Tables:        restorePgTables,
+			ExcludeTables: restorePgExclude,
+		})
+	default:
+		err = backup.RestorePlugin(restorePlugin, backupFilePath, restorePluginOptions)
+	}
+	if err != nil {
 		// Record failure metrics
 		metrics.RestoreFailure.WithLabelValues("restore_failed").Inc()
 		return fmt.Errorf("restore failed: %w", err)
@@ -228,10 +596,31 @@ func runRestore(cmd *cobra.Command, args []string) error {
 	metrics.RestoreDuration.Observe(duration)
 	metrics.RestoreSuccess.Inc()
 
-	// Cleanup downloaded file if needed
+	// Cleanup decrypted file if needed
 	if cleanupFile {
 		os.Remove(backupFilePath)
 	}
 
 	return nil
 }
+
+// restoreDownloadPath returns the local path a backup downloaded for a restore
+// is kept at. It only depends on where the backup comes from, so re-running an
+// interrupted restore resumes the same download.
+func restoreDownloadPath(dir, source, objectName string) string {
+	sum := sha256.Sum256([]byte(source))
+	return filepath.Join(dir, hex.EncodeToString(sum[:6])+"-"+filepath.Base(objectName))
+}
+
+// parseTargetTime parses a recovery target given as RFC 3339 or as local
+// "YYYY-MM-DD HH:MM:SS"
+func parseTargetTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --target-time %q: use RFC 3339 or \"YYYY-MM-DD HH:MM:SS\"", value)
+	}
+	return t, nil
+}
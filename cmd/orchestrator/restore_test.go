@@ -0,0 +1,42 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRestoreDownloadPath(t *testing.T) {
+	dir := t.TempDir()
+	object := "backups/2025/12/shop-20251209-092658.tar.gz"
+
+	path := restoreDownloadPath(dir, "oci://ns/dr-backups/"+object, object)
+	if filepath.Dir(path) != dir {
+		t.Errorf("%s is not in the download directory", path)
+	}
+	if !strings.HasSuffix(path, "-shop-20251209-092658.tar.gz") {
+		t.Errorf("path = %s, want the object's file name", path)
+	}
+
+	// A re-run finds the partial download of the same object again
+	if again := restoreDownloadPath(dir, "oci://ns/dr-backups/"+object, object); again != path {
+		t.Errorf("second run downloads to %s, first to %s", again, path)
+	}
+
+	// The same file name in another bucket does not share the partial file
+	if other := restoreDownloadPath(dir, "oci://ns/other/"+object, object); other == path {
+		t.Errorf("objects of different buckets share %s", path)
+	}
+}
+
+func TestRestorePITRNeedsPhysicalBackup(t *testing.T) {
+	file, backupType, pitr := restoreFile, restoreType, restorePITR
+	t.Cleanup(func() { restoreFile, restoreType, restorePITR = file, backupType, pitr })
+
+	// A logical dump cannot be laid out as a data directory
+	restoreFile, restoreType, restorePITR = "shop.tar.gz", "postgres", true
+	err := runRestore(restoreCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "only supported for postgres-physical") {
+		t.Fatalf("err = %v, want --pitr to be rejected for postgres", err)
+	}
+}
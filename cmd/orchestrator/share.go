@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/oracle"
+	"github.com/spf13/cobra"
+)
+
+var shareCmd = &cobra.Command{
+	Use:   "share",
+	Short: "Share a backup with a time-limited pre-authenticated URL",
+	Long: `Manage pre-authenticated requests (PARs) for backups in Oracle Cloud Object Storage.
+
+A PAR is an HTTPS URL that grants read access to a single object until it
+expires, so a recovery host without OCI credentials can fetch a backup with
+"orchestrator restore --from-cloud <url>" or "orchestrator download --object <url>".
+
+Example:
+  orchestrator share create --object backups/2025/12/prod-db.tar.gz --expires 4h
+  orchestrator share list
+  orchestrator share revoke --id <par-id>`,
+}
+
+var shareCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a read-only URL for a backup",
+	Long: `Create a read-only pre-authenticated URL for a single backup object.
+
+The URL is printed once and cannot be retrieved again; anyone holding it can
+download the object until it expires or is revoked.
+
+Example:
+  orchestrator share create --object backups/2025/12/prod-db.tar.gz --expires 4h`,
+	RunE: runShareCreate,
+}
+
+var shareListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List existing pre-authenticated requests",
+	Long: `List the pre-authenticated requests in the bucket.
+
+Example:
+  orchestrator share list
+  orchestrator share list --prefix backups/2025/`,
+	RunE: runShareList,
+}
+
+var shareRevokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Revoke a pre-authenticated request",
+	Long: `Revoke a pre-authenticated request so its URL stops working immediately.
+
+Example:
+  orchestrator share revoke --id <par-id>`,
+	RunE: runShareRevoke,
+}
+
+var (
+	shareObject  string
+	shareExpires time.Duration
+	shareName    string
+	sharePrefix  string
+	shareID      string
+)
+
+func init() {
+	rootCmd.AddCommand(shareCmd)
+	shareCmd.AddCommand(shareCreateCmd)
+	shareCmd.AddCommand(shareListCmd)
+	shareCmd.AddCommand(shareRevokeCmd)
+
+	// create flags
+	shareCreateCmd.Flags().StringVar(&shareObject, "object", "", "Object to share (required)")
+	shareCreateCmd.Flags().DurationVar(&shareExpires, "expires", 24*time.Hour, "How long the URL stays valid, e.g. 30m, 4h")
+	shareCreateCmd.Flags().StringVar(&shareName, "name", "", "Name of the pre-authenticated request (default: derived from the object)")
+	addStorageFlags(shareCreateCmd)
+	shareCreateCmd.MarkFlagRequired("object")
+
+	// list flags
+	shareListCmd.Flags().StringVar(&sharePrefix, "prefix", "", "Only list requests for objects with this prefix")
+	addStorageFlags(shareListCmd)
+
+	// revoke flags
+	shareRevokeCmd.Flags().StringVar(&shareID, "id", "", "ID of the pre-authenticated request to revoke (required)")
+	addStorageFlags(shareRevokeCmd)
+	shareRevokeCmd.MarkFlagRequired("id")
+}
+
+// newShareClient connects to the storage backend, which must be Oracle Cloud
+func newShareClient() (*oracle.Client, error) {
+	fmt.Printf("🔗 Connecting to %s storage...\n", resolveBackendName())
+
+	backend, err := newStorageBackend()
+	if err != nil {
+		return nil, err
+	}
+
+	client, ok := backend.(*oracle.Client)
+	if !ok {
+		return nil, fmt.Errorf("sharing is only supported by the oracle backend")
+	}
+
+	fmt.Printf("✓ Connected to: %s\n", client.Location())
+	return client, nil
+}
+
+func runShareCreate(cmd *cobra.Command, args []string) error {
+	client, err := newShareClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	link, err := client.CreateSharedLink(ctx, shareObject, shareExpires, shareName)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n✓ Shared link created!\n")
+	fmt.Printf("  Object: %s\n", link.ObjectName)
+	fmt.Printf("  ID: %s\n", link.ID)
+	fmt.Printf("  Expires: %s\n", link.TimeExpires.Format(time.RFC3339))
+	fmt.Printf("  URL: %s\n", link.URL)
+	fmt.Printf("\n⚠️  Anyone with this URL can download the backup. It is not shown again.\n")
+	fmt.Printf("  Restore with: orchestrator restore --from-cloud '%s' --db-name <db>\n", link.URL)
+
+	return nil
+}
+
+func runShareList(cmd *cobra.Command, args []string) error {
+	client, err := newShareClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	links, err := client.ListSharedLinks(ctx, sharePrefix)
+	if err != nil {
+		return err
+	}
+
+	if len(links) == 0 {
+		fmt.Println("\nNo shared links found.")
+		return nil
+	}
+
+	fmt.Println()
+	now := time.Now()
+	for i, link := range links {
+		fmt.Printf("%d. %s\n", i+1, link.Name)
+		fmt.Printf("   ID: %s\n", link.ID)
+		fmt.Printf("   Object: %s\n", link.ObjectName)
+		fmt.Printf("   Created: %s\n", link.TimeCreated.Format("2006-01-02 15:04:05"))
+		if link.TimeExpires.Before(now) {
+			fmt.Printf("   Expired: %s\n", link.TimeExpires.Format("2006-01-02 15:04:05"))
+		} else {
+			fmt.Printf("   Expires: %s\n", link.TimeExpires.Format("2006-01-02 15:04:05"))
+		}
+		fmt.Println()
+	}
+	fmt.Printf("Total: %d shared link(s)\n", len(links))
+
+	return nil
+}
+
+func runShareRevoke(cmd *cobra.Command, args []string) error {
+	client, err := newShareClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if err := client.RevokeSharedLink(ctx, shareID); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Shared link revoked: %s\n", shareID)
+	return nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/filesystem"
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/oracle"
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/s3"
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+// Storage backend names accepted by --backend
+const (
+	backendOracle = "oracle"
+	backendS3     = "s3"
+	backendFS     = "filesystem"
+)
+
+var (
+	storageBackend string
+	ociAuth        string
+	ociConfigFile  string
+	ociProfile     string
+	ociBucket      string
+	ociNamespace   string
+	ociCompartment string
+	ociPartSizeMB  int64
+	ociParallel    int
+	ociMaxRetries  int
+	ociTier        string
+	rehydrateHours int
+	rehydratePoll  time.Duration
+	s3Endpoint     string
+	s3Region       string
+	s3AccessKey    string
+	s3SecretKey    string
+	s3PathStyle    bool
+	s3Insecure     bool
+	fsRoot         string
+)
+
+// addStorageFlags registers the flags used to select and configure a storage backend
+func addStorageFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&storageBackend, "backend", "", "Storage backend: oracle, s3, filesystem (or use STORAGE_BACKEND env var or storage.backend in the config file, default: oracle)")
+
+	// Oracle Cloud flags
+	cmd.Flags().StringVar(&ociAuth, "oci-auth", "", "OCI auth mode: api_key, security_token, instance_principal, resource_principal, oke_workload_identity, env (default: auto-detect)")
+	cmd.Flags().StringVar(&ociConfigFile, "oci-config", "", "Path to OCI config file (default: ~/.oci/config)")
+	cmd.Flags().StringVar(&ociProfile, "oci-profile", "DEFAULT", "OCI config profile to use")
+	cmd.Flags().StringVar(&ociBucket, "bucket", "", "Object Storage bucket name")
+	cmd.Flags().StringVar(&ociNamespace, "namespace", "", "OCI namespace (auto-detected if not provided)")
+	cmd.Flags().StringVar(&ociCompartment, "compartment", "", "OCI compartment ID")
+
+	// S3-compatible flags
+	cmd.Flags().StringVar(&s3Endpoint, "s3-endpoint", "", "S3 endpoint, e.g. http://localhost:9000 for MinIO (default: s3.amazonaws.com)")
+	cmd.Flags().StringVar(&s3Region, "s3-region", "", "S3 region (or use AWS_REGION env var)")
+	cmd.Flags().StringVar(&s3AccessKey, "s3-access-key", "", "S3 access key (or use AWS_ACCESS_KEY_ID env var)")
+	cmd.Flags().StringVar(&s3SecretKey, "s3-secret-key", "", "S3 secret key (or use AWS_SECRET_ACCESS_KEY env var)")
+	cmd.Flags().BoolVar(&s3PathStyle, "s3-path-style", false, "Use path-style addressing (required by most MinIO and Ceph RGW setups)")
+	cmd.Flags().BoolVar(&s3Insecure, "s3-insecure", false, "Use plain HTTP to talk to the S3 endpoint")
+
+	// Filesystem flags
+	cmd.Flags().StringVar(&fsRoot, "fs-root", "", "Root directory for the filesystem backend (e.g. an NFS or USB mount)")
+}
+
+// resolveBackendName returns the backend selected by flag, environment,
+// config file or default
+func resolveBackendName() string {
+	if storageBackend != "" {
+		return storageBackend
+	}
+	if env := os.Getenv("STORAGE_BACKEND"); env != "" {
+		return env
+	}
+	if fileConfig.Storage.Backend != "" {
+		return fileConfig.Storage.Backend
+	}
+	return backendOracle
+}
+
+// newStorageBackend creates the storage backend selected by --backend
+func newStorageBackend() (storage.Backend, error) {
+	switch name := resolveBackendName(); name {
+	case backendOracle:
+		if ociBucket == "" || ociCompartment == "" {
+			return nil, fmt.Errorf("--bucket and --compartment are required for the oracle backend")
+		}
+
+		authMode, err := oracle.ParseAuthMode(ociAuth)
+		if err != nil {
+			return nil, err
+		}
+
+		tier, err := oracle.ParseStorageTier(ociTier)
+		if err != nil {
+			return nil, err
+		}
+
+		config := oracle.Config{
+			AuthMode:       authMode,
+			StorageTier:    tier,
+			ConfigFilePath: ociConfigFile,
+			Profile:        ociProfile,
+			Namespace:      ociNamespace,
+			BucketName:     ociBucket,
+			CompartmentID:  ociCompartment,
+			PartSize:       ociPartSizeMB * 1024 * 1024,
+			Parallelism:    ociParallel,
+			MaxRetries:     ociMaxRetries,
+		}
+
+		client, err := oracle.NewClient(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OCI client: %w", err)
+		}
+		return client, nil
+	case backendS3:
+		if ociBucket == "" {
+			return nil, fmt.Errorf("--bucket is required for the s3 backend")
+		}
+
+		region := s3Region
+		if region == "" {
+			region = os.Getenv("AWS_REGION")
+		}
+
+		config := s3.Config{
+			Endpoint:        s3Endpoint,
+			Region:          region,
+			BucketName:      ociBucket,
+			AccessKeyID:     s3AccessKey,
+			SecretAccessKey: s3SecretKey,
+			UsePathStyle:    s3PathStyle,
+			Insecure:        s3Insecure,
+		}
+
+		client, err := s3.NewClient(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create S3 client: %w", err)
+		}
+		return client, nil
+	case backendFS:
+		if fsRoot == "" {
+			return nil, fmt.Errorf("--fs-root is required for the filesystem backend")
+		}
+
+		client, err := filesystem.NewClient(filesystem.Config{RootPath: fsRoot})
+		if err != nil {
+			return nil, fmt.Errorf("failed to open filesystem backend: %w", err)
+		}
+		return client, nil
+	default:
+		return nil, fmt.Errorf("unsupported storage backend: %s (supported: %s, %s, %s)", name, backendOracle, backendS3, backendFS)
+	}
+}
+
+// addRehydrateFlags registers the flags controlling archive rehydration
+func addRehydrateFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&rehydrateHours, "restore-hours", oracle.DefaultRestoreHours, "Hours an archived object stays readable after rehydration")
+	cmd.Flags().DurationVar(&rehydratePoll, "poll-interval", oracle.DefaultPollInterval, "How often to check whether an archived object has been rehydrated")
+}
+
+// validateRehydrateFlags rejects rehydration settings that cannot be used
+func validateRehydrateFlags() error {
+	if rehydratePoll <= 0 {
+		return fmt.Errorf("--poll-interval must be positive, got %s", rehydratePoll)
+	}
+	return nil
+}
+
+// ensureReadable rehydrates an archived object, waiting until it can be read
+func ensureReadable(ctx context.Context, backend storage.Backend, objectName string) error {
+	rehydrator, ok := backend.(storage.Rehydrator)
+	if !ok {
+		return nil
+	}
+
+	info, err := backend.Stat(ctx, objectName)
+	if err != nil {
+		return err
+	}
+	if !info.NeedsRehydration() {
+		return nil
+	}
+
+	fmt.Printf("🧊 Object is in Archive tier (%s), rehydrating for %d hours...\n", info.ArchivalState, rehydrateHours)
+	fmt.Printf("   This usually takes about an hour; checking every %s\n", rehydratePoll)
+
+	waitStart := time.Now()
+	if err := rehydrator.Rehydrate(ctx, objectName, rehydrateHours, rehydratePoll); err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Object rehydrated after %s\n", time.Since(waitStart).Round(time.Second))
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/oracle"
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var tierCmd = &cobra.Command{
+	Use:   "tier",
+	Short: "Move backups between storage tiers (Standard, InfrequentAccess, Archive)",
+	Long: `Transition existing backups in Oracle Cloud Object Storage to another storage tier.
+Archive tier is the cheapest place for long-term backups; archived objects are
+rehydrated automatically by download and restore --from-cloud.
+
+Examples:
+  # Archive a single backup
+  orchestrator tier --object backups/2025/01/prod-db-monthly.tar.gz --to archive
+
+  # Archive every backup from 2024
+  orchestrator tier --prefix backups/2024/ --to archive
+
+  # Archive backups older than 90 days
+  orchestrator tier --prefix backups/ --older-than 2160h --to archive`,
+	RunE: runTier,
+}
+
+var (
+	tierObject    string
+	tierPrefix    string
+	tierTarget    string
+	tierOlderThan time.Duration
+	tierDryRun    bool
+)
+
+func init() {
+	rootCmd.AddCommand(tierCmd)
+
+	tierCmd.Flags().StringVar(&tierObject, "object", "", "Object to transition")
+	tierCmd.Flags().StringVar(&tierPrefix, "prefix", "", "Transition every object with this prefix")
+	tierCmd.Flags().StringVar(&tierTarget, "to", "", "Target tier: standard, infrequent, archive (required)")
+	tierCmd.Flags().DurationVar(&tierOlderThan, "older-than", 0, "Only transition objects last modified before this age (with --prefix)")
+	tierCmd.Flags().BoolVar(&tierDryRun, "dry-run", false, "Show what would be transitioned without changing anything")
+	addStorageFlags(tierCmd)
+
+	tierCmd.MarkFlagRequired("to")
+}
+
+func runTier(cmd *cobra.Command, args []string) error {
+	if (tierObject == "") == (tierPrefix == "") {
+		return fmt.Errorf("exactly one of --object or --prefix must be specified")
+	}
+
+	// An empty tier means "bucket default" to ParseStorageTier, which an
+	// existing object cannot be moved to
+	if tierTarget == "" {
+		return fmt.Errorf("--to must name a tier: standard, infrequent, archive")
+	}
+	tier, err := oracle.ParseStorageTier(tierTarget)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("🔗 Connecting to %s storage...\n", resolveBackendName())
+
+	backend, err := newStorageBackend()
+	if err != nil {
+		return err
+	}
+
+	client, ok := backend.(*oracle.Client)
+	if !ok {
+		return fmt.Errorf("storage tiers are only supported by the oracle backend")
+	}
+
+	fmt.Printf("✓ Connected to: %s\n", client.Location())
+
+	ctx := context.Background()
+
+	// Collect the objects to transition
+	var objects []storage.ObjectInfo
+	if tierObject != "" {
+		info, err := client.Stat(ctx, tierObject)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, *info)
+	} else {
+		opts := storage.ListOptions{
+			Prefix: tierPrefix,
+			Fields: []string{storage.FieldStorageTier},
+		}
+		cutoff := time.Now().Add(-tierOlderThan)
+		err := client.Walk(ctx, opts, func(obj storage.ObjectInfo) error {
+			if tierOlderThan > 0 && obj.LastModified.After(cutoff) {
+				return nil
+			}
+			objects = append(objects, obj)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list objects: %w", err)
+		}
+	}
+
+	fmt.Printf("🗄️  Moving %d object(s) to %s tier\n\n", len(objects), tier)
+
+	var moved, skipped int
+	for _, obj := range objects {
+		if obj.StorageTier == string(tier) {
+			skipped++
+			continue
+		}
+
+		if tierDryRun {
+			fmt.Printf("  would move: %s (%s → %s)\n", obj.Name, obj.StorageTier, tier)
+			moved++
+			continue
+		}
+
+		if err := client.SetStorageTier(ctx, obj.Name, tier); err != nil {
+			return err
+		}
+		fmt.Printf("  ✓ %s\n", obj.Name)
+		moved++
+	}
+
+	fmt.Printf("\n✓ %d moved, %d already in %s\n", moved, skipped, tier)
+
+	return nil
+}
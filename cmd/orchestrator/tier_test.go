@@ -0,0 +1,17 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTierRejectsEmptyTarget(t *testing.T) {
+	object, target := tierObject, tierTarget
+	t.Cleanup(func() { tierObject, tierTarget = object, target })
+
+	tierObject, tierTarget = "backups/2025/12/shop.tar.gz", ""
+	err := runTier(tierCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "--to must name a tier") {
+		t.Fatalf("err = %v, want an empty --to to be rejected", err)
+	}
+}
@@ -4,48 +4,66 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/backup"
 	"github.com/Kobeep/cloud-dr-orchestrator/pkg/metrics"
 	"github.com/Kobeep/cloud-dr-orchestrator/pkg/oracle"
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
 	"github.com/spf13/cobra"
 )
 
 var uploadCmd = &cobra.Command{
 	Use:   "upload",
-	Short: "Upload a backup file to Oracle Cloud Object Storage",
-	Long: `Upload a local backup file to Oracle Cloud Object Storage.
+	Short: "Upload a backup file to cloud storage",
+	Long: `Upload a local backup file to the configured storage backend (default: Oracle Cloud Object Storage).
 The file will be organized in a date-based folder structure (backups/YYYY/MM/filename).
 
+Files larger than --part-size are uploaded as a parallel multipart upload.
+Failed parts are retried, and if the upload is interrupted its progress is kept
+in <file>.upload-state.json so running the same command again resumes it.
+
+Metadata written by the backup command (<file>.meta.json) is attached to the
+object, together with any --meta key=value pairs, so backups can be filtered
+with "orchestrator list --filter".
+
 Example:
-  orchestrator upload --file backup-20251209.tar.gz`,
+  orchestrator upload --file backup-20251209.tar.gz
+
+  # 80 GB dump with 256 MB parts, 8 at a time
+  orchestrator upload --file big.tar.gz --part-size 256 --parallel 8
+
+  # Tag the object with extra metadata
+  orchestrator upload --file backup.tar.gz --meta environment=prod`,
 	RunE: runUpload,
 }
 
 var (
 	uploadFile       string
 	uploadObjectName string
-	ociConfigFile    string
-	ociProfile       string
-	ociBucket        string
-	ociNamespace     string
-	ociCompartment   string
+	uploadTimeout    time.Duration
+	uploadAbort      bool
+	uploadMeta       map[string]string
 )
 
 func init() {
 	rootCmd.AddCommand(uploadCmd)
 
 	uploadCmd.Flags().StringVar(&uploadFile, "file", "", "Path to the backup file to upload (required)")
-	uploadCmd.Flags().StringVar(&uploadObjectName, "object-name", "", "Custom object name in Object Storage (optional, uses filename if not set)")
-	uploadCmd.Flags().StringVar(&ociConfigFile, "oci-config", "", "Path to OCI config file (default: ~/.oci/config)")
-	uploadCmd.Flags().StringVar(&ociProfile, "oci-profile", "DEFAULT", "OCI config profile to use")
-	uploadCmd.Flags().StringVar(&ociBucket, "bucket", "", "OCI Object Storage bucket name (required)")
-	uploadCmd.Flags().StringVar(&ociNamespace, "namespace", "", "OCI namespace (auto-detected if not provided)")
-	uploadCmd.Flags().StringVar(&ociCompartment, "compartment", "", "OCI compartment ID (required)")
+	uploadCmd.Flags().StringVar(&uploadObjectName, "object-name", "", "Custom object name in Object Storage (default: backups/YYYY/MM/<filename>)")
+	uploadCmd.Flags().StringToStringVar(&uploadMeta, "meta", map[string]string{}, "Extra object metadata as key=value (can be specified multiple times)")
+	uploadCmd.Flags().DurationVar(&uploadTimeout, "timeout", 0, "Abort the upload after this duration, e.g. 2h (default: no timeout)")
+	addStorageFlags(uploadCmd)
+
+	// Multipart upload flags (oracle backend)
+	uploadCmd.Flags().Int64Var(&ociPartSizeMB, "part-size", oracle.DefaultPartSize/1024/1024, "Multipart part size in MB; larger files are uploaded in parts")
+	uploadCmd.Flags().IntVar(&ociParallel, "parallel", oracle.DefaultParallelism, "Number of parts uploaded concurrently")
+	uploadCmd.Flags().IntVar(&ociMaxRetries, "retries", oracle.DefaultMaxRetries, "Attempts per part before giving up")
+	uploadCmd.Flags().StringVar(&ociTier, "tier", "", "Storage tier for the object: standard, infrequent, archive (default: bucket default)")
+	uploadCmd.Flags().BoolVar(&uploadAbort, "abort", false, "Abort the interrupted multipart upload of --file instead of resuming it")
 
 	uploadCmd.MarkFlagRequired("file")
-	uploadCmd.MarkFlagRequired("bucket")
-	uploadCmd.MarkFlagRequired("compartment")
 }
 
 func runUpload(cmd *cobra.Command, args []string) error {
@@ -54,39 +72,61 @@ func runUpload(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("file does not exist: %s", uploadFile)
 	}
 
-	fmt.Printf("🔗 Connecting to Oracle Cloud...\n")
+	fmt.Printf("🔗 Connecting to %s storage...\n", resolveBackendName())
 
-	// Create OCI client
-	config := oracle.Config{
-		ConfigFilePath: ociConfigFile,
-		Profile:        ociProfile,
-		Namespace:      ociNamespace,
-		BucketName:     ociBucket,
-		CompartmentID:  ociCompartment,
-	}
-
-	client, err := oracle.NewClient(config)
+	// Create storage backend
+	backend, err := newStorageBackend()
 	if err != nil {
-		return fmt.Errorf("failed to create OCI client: %w", err)
+		return err
 	}
 
-	fmt.Printf("✓ Connected to namespace: %s\n", client.GetNamespace())
+	fmt.Printf("✓ Connected to: %s\n", backend.Location())
 	fmt.Printf("📤 Uploading file: %s\n", uploadFile)
 
 	// Start timing for metrics
 	startTime := time.Now()
 
 	// Upload the file
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
-	defer cancel()
-
-	var result *oracle.UploadResult
-	if uploadObjectName != "" {
-		result, err = client.UploadFile(ctx, uploadFile, uploadObjectName)
-	} else {
-		result, err = client.UploadBackup(ctx, uploadFile)
+	ctx := context.Background()
+	if uploadTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, uploadTimeout)
+		defer cancel()
+	}
+
+	if uploadAbort {
+		client, ok := backend.(*oracle.Client)
+		if !ok {
+			return fmt.Errorf("--abort is only supported by the oracle backend")
+		}
+		if err := client.AbortUpload(ctx, uploadFile); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Interrupted upload aborted\n")
+		return nil
+	}
+
+	if _, err := os.Stat(oracle.StateFilePath(uploadFile)); err == nil {
+		fmt.Printf("↻ Resuming interrupted upload\n")
+	}
+
+	// Attach the metadata recorded at backup time plus any --meta values
+	metadata, err := backup.ReadMetadataFile(uploadFile)
+	if err != nil {
+		return err
+	}
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	for key, value := range uploadMeta {
+		metadata[strings.ToLower(key)] = value
+	}
+	if len(metadata) > 0 {
+		fmt.Printf("🏷️  Attaching %d metadata field(s)\n", len(metadata))
 	}
 
+	// An empty object name places the file under backups/YYYY/MM/
+	result, err := storage.UploadFile(ctx, backend, uploadFile, uploadObjectName, metadata)
 	if err != nil {
 		// Record failure metrics
 		metrics.UploadFailure.WithLabelValues("upload_failed").Inc()
@@ -101,7 +141,7 @@ func runUpload(cmd *cobra.Command, args []string) error {
 	// Print success message
 	fmt.Printf("\n✓ Upload successful!\n")
 	fmt.Printf("  Object: %s\n", result.ObjectName)
-	fmt.Printf("  Bucket: %s\n", result.BucketName)
+	fmt.Printf("  Location: %s\n", result.Location)
 	fmt.Printf("  Size: %.2f MB\n", float64(result.Size)/1024/1024)
 	fmt.Printf("  Duration: %s\n", result.Duration.Round(time.Millisecond))
 	fmt.Printf("  ETag: %s\n", result.ETag)
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/backup"
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/encryption"
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+var walPushCmd = &cobra.Command{
+	Use:   "wal-push <wal-path>",
+	Short: "Archive a PostgreSQL WAL file to storage (archive_command)",
+	Long: `Compress, optionally encrypt, and upload a WAL file to the storage backend
+under wal/<name>/. Meant to be PostgreSQL's archive_command, for continuous
+archiving and point-in-time recovery with
+"orchestrator restore --type postgres-physical --pitr".
+
+Pushing a file that is already archived with the same content succeeds, so
+PostgreSQL can safely retry after a crash; different content is an error.
+
+Example postgresql.conf:
+  archive_mode = on
+  archive_command = 'orchestrator wal-push %p --name main --backend s3 --bucket dr-backups --encrypt'
+
+Credentials and the encryption key are read from the server's environment
+(AWS_ACCESS_KEY_ID, BACKUP_ENCRYPTION_KEY, ...), not the command line.`,
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE:          runWALPush,
+}
+
+var walFetchCmd = &cobra.Command{
+	Use:   "wal-fetch <wal-file> <destination>",
+	Short: "Fetch an archived PostgreSQL WAL file from storage (restore_command)",
+	Long: `Download a WAL file archived with wal-push, decrypt and decompress it to the
+destination path. Meant to be PostgreSQL's restore_command; it is configured
+automatically by "orchestrator restore --pitr".
+
+A file that is not in the archive makes the command fail, which is how
+PostgreSQL learns it has reached the end of the archived WAL.
+
+Example postgresql.conf:
+  restore_command = 'orchestrator wal-fetch %f "%p" --name main --backend s3 --bucket dr-backups'`,
+	Args:          cobra.ExactArgs(2),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE:          runWALFetch,
+}
+
+var (
+	walCluster       string
+	walEncrypt       bool
+	walEncryptionKey string
+	walDecryptionKey string
+)
+
+// walFetchStorageFlags are the storage flags passed on to wal-fetch in a
+// generated restore_command. Credentials are left to the server's environment.
+var walFetchStorageFlags = []string{
+	"oci-auth", "oci-config", "oci-profile", "bucket", "namespace", "compartment",
+	"s3-endpoint", "s3-region", "s3-path-style", "s3-insecure", "fs-root",
+}
+
+func init() {
+	rootCmd.AddCommand(walPushCmd)
+	rootCmd.AddCommand(walFetchCmd)
+
+	walPushCmd.Flags().StringVar(&walCluster, "name", "", "Cluster name the WAL is archived under (required)")
+	walPushCmd.Flags().BoolVar(&walEncrypt, "encrypt", false, "Encrypt WAL files before upload")
+	walPushCmd.Flags().StringVar(&walEncryptionKey, "encryption-key", "", "Encryption key (or use BACKUP_ENCRYPTION_KEY env var)")
+	walPushCmd.Flags().StringVar(&keyLabel, "key-label", "", "Name for the encryption key stored in the WAL metadata instead of a fingerprint of the key")
+	walPushCmd.MarkFlagRequired("name")
+	addStorageFlags(walPushCmd)
+
+	walFetchCmd.Flags().StringVar(&walCluster, "name", "", "Cluster name the WAL is archived under (required)")
+	walFetchCmd.Flags().StringVar(&walDecryptionKey, "decryption-key", "", "Decryption key for encrypted WAL (or use BACKUP_ENCRYPTION_KEY env var)")
+	walFetchCmd.MarkFlagRequired("name")
+	addStorageFlags(walFetchCmd)
+	addRehydrateFlags(walFetchCmd)
+}
+
+func runWALPush(cmd *cobra.Command, args []string) error {
+	walPath := args[0]
+	walFile := filepath.Base(walPath)
+	if !backup.IsWALFileName(walFile) {
+		return fmt.Errorf("%s is not a WAL file name", walFile)
+	}
+
+	key := walEncryptionKey
+	if walEncrypt && key == "" {
+		key = os.Getenv("BACKUP_ENCRYPTION_KEY")
+	}
+	if walEncrypt && key == "" {
+		return fmt.Errorf("encryption key required when --encrypt is enabled")
+	}
+
+	backend, err := newStorageBackend()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	// archive_command is retried after a crash, possibly after the upload
+	// finished; only identical content may already be there
+	existing, err := findArchivedWAL(ctx, backend, walFile)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		sum, err := backup.WALFileSHA256(walPath)
+		if err != nil {
+			return err
+		}
+		if storage.NormalizeMetadata(existing.Metadata)[backup.MetaWALSHA256] != sum {
+			return fmt.Errorf("%s is already archived as %s with different content", walFile, existing.Name)
+		}
+		fmt.Printf("wal-push: %s already archived\n", walFile)
+		return nil
+	}
+
+	tempDir, err := os.MkdirTemp("", "orchestrator-wal-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	localPath := filepath.Join(tempDir, walFile+".gz")
+	sum, err := backup.CompressWAL(walPath, localPath)
+	if err != nil {
+		return err
+	}
+
+	metadata := map[string]string{backup.MetaWALSHA256: sum}
+	if walEncrypt {
+		if localPath, err = encryption.EncryptFile(localPath, key); err != nil {
+			return fmt.Errorf("encryption failed: %w", err)
+		}
+		metadata[backup.MetaEncryptionKeyID] = encryptionKeyID(key, keyLabel)
+	}
+
+	objectName := storage.WALObjectName(walCluster, filepath.Base(localPath))
+	if _, err := storage.UploadFile(ctx, backend, localPath, objectName, metadata); err != nil {
+		return fmt.Errorf("upload failed: %w", err)
+	}
+
+	fmt.Printf("wal-push: archived %s as %s\n", walFile, objectName)
+	return nil
+}
+
+func runWALFetch(cmd *cobra.Command, args []string) error {
+	walFile, destination := args[0], args[1]
+	if !backup.IsWALFileName(walFile) {
+		return fmt.Errorf("%s is not a WAL file name", walFile)
+	}
+	if err := validateRehydrateFlags(); err != nil {
+		return err
+	}
+
+	backend, err := newStorageBackend()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	archived, err := findArchivedWAL(ctx, backend, walFile)
+	if err != nil {
+		return err
+	}
+	if archived == nil {
+		return fmt.Errorf("%s not found in archive", walFile)
+	}
+
+	tempDir, err := os.MkdirTemp("", "orchestrator-wal-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := ensureReadable(ctx, backend, archived.Name); err != nil {
+		return fmt.Errorf("failed to rehydrate %s: %w", archived.Name, err)
+	}
+
+	localPath := filepath.Join(tempDir, filepath.Base(archived.Name))
+	if _, err := storage.DownloadFile(ctx, backend, archived.Name, localPath); err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+
+	if encryption.IsEncrypted(localPath) {
+		key := walDecryptionKey
+		if key == "" {
+			key = os.Getenv("BACKUP_ENCRYPTION_KEY")
+		}
+		if key == "" {
+			return fmt.Errorf("%s is encrypted but no decryption key provided (use --decryption-key or BACKUP_ENCRYPTION_KEY env var)", archived.Name)
+		}
+		if localPath, err = encryption.DecryptFile(localPath, key); err != nil {
+			return fmt.Errorf("decryption failed: %w", err)
+		}
+	}
+
+	if err := backup.DecompressWAL(localPath, destination); err != nil {
+		return err
+	}
+
+	fmt.Printf("wal-fetch: restored %s\n", walFile)
+	return nil
+}
+
+// findArchivedWAL returns the archived object for a WAL file, encrypted or
+// not, or nil if it has not been archived
+func findArchivedWAL(ctx context.Context, backend storage.Backend, walFile string) (*storage.ObjectInfo, error) {
+	base := storage.WALObjectName(walCluster, walFile) + ".gz"
+
+	var found *storage.ObjectInfo
+	opts := storage.ListOptions{Prefix: base, Fields: []string{storage.FieldMetadata}}
+	err := storage.Walk(ctx, backend, opts, func(obj storage.ObjectInfo) error {
+		if obj.Name == base || obj.Name == base+".encrypted" {
+			found = &obj
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up %s in archive: %w", walFile, err)
+	}
+
+	return found, nil
+}
+
+// walFetchRestoreCommand builds the restore_command fetching WAL of a cluster
+// with the storage settings given to cmd
+func walFetchRestoreCommand(cmd *cobra.Command, cluster string) (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate orchestrator executable: %w", err)
+	}
+
+	args := []string{"--name", cluster, "--backend", resolveBackendName()}
+	for _, name := range walFetchStorageFlags {
+		if !cmd.Flags().Changed(name) {
+			continue
+		}
+		value := cmd.Flags().Lookup(name).Value.String()
+		// The server runs restore_command from its data directory
+		if name == "fs-root" || name == "oci-config" {
+			if value, err = filepath.Abs(value); err != nil {
+				return "", fmt.Errorf("invalid --%s: %w", name, err)
+			}
+		}
+		args = append(args, "--"+name+"="+value)
+	}
+
+	if cmd.Flags().Changed("s3-access-key") || cmd.Flags().Changed("s3-secret-key") {
+		fmt.Printf("⚠️  S3 credentials are not written to restore_command; set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY for the PostgreSQL server\n")
+	}
+
+	return backup.WALFetchCommand(executable, args...), nil
+}
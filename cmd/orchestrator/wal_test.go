@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/backup"
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
+)
+
+// useWALArchive points the wal commands at a filesystem archive in a temp
+// directory and restores the flag values afterwards
+func useWALArchive(t *testing.T, encrypt bool) {
+	t.Helper()
+
+	backendName, root, cluster, enc, key := storageBackend, fsRoot, walCluster, walEncrypt, walEncryptionKey
+	t.Cleanup(func() {
+		storageBackend, fsRoot, walCluster, walEncrypt, walEncryptionKey = backendName, root, cluster, enc, key
+	})
+
+	storageBackend = backendFS
+	fsRoot = t.TempDir()
+	walCluster = "main"
+	walEncrypt = encrypt
+	walEncryptionKey = ""
+	if encrypt {
+		walEncryptionKey = "correct horse battery staple"
+	}
+}
+
+// archivedWAL returns the objects archived for the cluster
+func archivedWAL(t *testing.T) []storage.ObjectInfo {
+	t.Helper()
+
+	backend, err := newStorageBackend()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var objects []storage.ObjectInfo
+	opts := storage.ListOptions{Prefix: storage.WALObjectName(walCluster, ""), Fields: []string{storage.FieldMetadata}}
+	err = storage.Walk(context.Background(), backend, opts, func(obj storage.ObjectInfo) error {
+		objects = append(objects, obj)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return objects
+}
+
+func TestWALPushIsIdempotent(t *testing.T) {
+	for _, encrypt := range []bool{false, true} {
+		name := "plain"
+		if encrypt {
+			name = "encrypted"
+		}
+		t.Run(name, func(t *testing.T) {
+			useWALArchive(t, encrypt)
+
+			walPath := filepath.Join(t.TempDir(), "000000010000000000000001")
+			segment := bytes.Repeat([]byte("wal record\n"), 1000)
+			if err := os.WriteFile(walPath, segment, 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := runWALPush(walPushCmd, []string{walPath}); err != nil {
+				t.Fatal(err)
+			}
+			objects := archivedWAL(t)
+			if len(objects) != 1 {
+				t.Fatalf("archived %d objects, want 1", len(objects))
+			}
+			first := objects[0]
+
+			sum, err := backup.WALFileSHA256(walPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := storage.NormalizeMetadata(first.Metadata)[backup.MetaWALSHA256]; got != sum {
+				t.Errorf("%s = %q, want %q", backup.MetaWALSHA256, got, sum)
+			}
+
+			// PostgreSQL retries archive_command after a crash: the same
+			// content is accepted without another upload
+			if err := runWALPush(walPushCmd, []string{walPath}); err != nil {
+				t.Fatalf("second push of the same segment failed: %v", err)
+			}
+			objects = archivedWAL(t)
+			if len(objects) != 1 || !objects[0].LastModified.Equal(first.LastModified) {
+				t.Errorf("second push changed the archive: %v", objects)
+			}
+
+			// Different content under an archived name must never replace it
+			segment[0] ^= 0xff
+			if err := os.WriteFile(walPath, segment, 0600); err != nil {
+				t.Fatal(err)
+			}
+			err = runWALPush(walPushCmd, []string{walPath})
+			if err == nil || !strings.Contains(err.Error(), "different content") {
+				t.Fatalf("err = %v, want a different content error", err)
+			}
+			if objects := archivedWAL(t); len(objects) != 1 || storage.NormalizeMetadata(objects[0].Metadata)[backup.MetaWALSHA256] != sum {
+				t.Errorf("archive changed after a conflicting push: %v", objects)
+			}
+		})
+	}
+}
+
+func TestWALPushRejectsNonWALFiles(t *testing.T) {
+	useWALArchive(t, false)
+
+	path := filepath.Join(t.TempDir(), "postgresql.conf")
+	if err := os.WriteFile(path, []byte("archive_mode = on\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := runWALPush(walPushCmd, []string{path}); err == nil {
+		t.Fatal("pushing a non-WAL file succeeded")
+	}
+	if objects := archivedWAL(t); len(objects) != 0 {
+		t.Errorf("archived %v", objects)
+	}
+}
@@ -0,0 +1,206 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+)
+
+// startEtcd runs a single member etcd server in a temporary directory and
+// returns it with its client endpoint
+func startEtcd(t *testing.T) (*embed.Etcd, string) {
+	t.Helper()
+
+	local, err := url.Parse("http://127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.ListenClientUrls = []url.URL{*local}
+	cfg.AdvertiseClientUrls = []url.URL{*local}
+	cfg.ListenPeerUrls = []url.URL{*local}
+	cfg.AdvertisePeerUrls = []url.URL{*local}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+	cfg.LogLevel = "error"
+
+	server, err := embed.StartEtcd(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+
+	select {
+	case <-server.Server.ReadyNotify():
+	case err := <-server.Err():
+		t.Fatal(err)
+	case <-time.After(30 * time.Second):
+		t.Fatal("etcd did not become ready")
+	}
+
+	return server, "http://" + server.Clients[0].Addr().String()
+}
+
+// putEtcdKeys writes a few keys and returns the store revision after them
+func putEtcdKeys(t *testing.T, endpoint string) int64 {
+	t.Helper()
+
+	client, err := EtcdConfig{}.newClient(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), etcdRequestTimeout)
+	defer cancel()
+
+	var resp *clientv3.PutResponse
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("/registry/configmaps/shop/settings-%d", i)
+		if resp, err = client.Put(ctx, key, strings.Repeat("x", 1000)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp.Header.GetRevision()
+}
+
+// readEtcdArchive returns the snapshot stored in a backup archive
+func readEtcdArchive(t *testing.T, archivePath string) []byte {
+	t.Helper()
+
+	archive, err := decompressStream(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+
+	tarReader := tar.NewReader(archive)
+	header, err := tarReader.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(header.Name, ".db") {
+		t.Fatalf("archive holds %s, want the snapshot", header.Name)
+	}
+	data, err := io.ReadAll(tarReader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestEtcdBackup(t *testing.T) {
+	server, endpoint := startEtcd(t)
+	revision := putEtcdKeys(t, endpoint)
+
+	eb := &EtcdBackup{Name: "etcd", Config: EtcdConfig{Endpoints: []string{endpoint}}}
+	outputPath := filepath.Join(t.TempDir(), "etcd-20251209-092658.tar.gz")
+	result, err := eb.Backup(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := readEtcdArchive(t, outputPath)
+	want := map[string]string{
+		MetaRevision:     strconv.FormatInt(revision, 10),
+		MetaSnapshotHash: hex.EncodeToString(snapshot[len(snapshot)-sha256.Size:]),
+		MetaClusterID:    fmt.Sprintf("%x", uint64(server.Server.Cluster().ID())),
+		MetaMembers:      "1",
+	}
+	for key, value := range want {
+		if result.Extra[key] != value {
+			t.Errorf("Extra[%s] = %q, want %q", key, result.Extra[key], value)
+		}
+	}
+	if result.OriginalSize != int64(len(snapshot)) || result.DatabaseName != endpoint {
+		t.Errorf("result original size %d endpoint %q, want %d %q", result.OriginalSize, result.DatabaseName, len(snapshot), endpoint)
+	}
+
+	// The archive holds the bolt database with etcd's hash trailer
+	if sum := sha256.Sum256(snapshot[:len(snapshot)-sha256.Size]); !bytes.Equal(sum[:], snapshot[len(snapshot)-sha256.Size:]) {
+		t.Error("archived snapshot does not end with the SHA-256 of the database")
+	}
+	if !bytes.Contains(snapshot, []byte("/registry/configmaps/shop/settings-19")) {
+		t.Error("archived snapshot does not contain the written keys")
+	}
+
+	// Only the compressed archive is left behind
+	entries, err := os.ReadDir(filepath.Dir(outputPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != filepath.Base(outputPath) {
+		t.Errorf("output directory holds %v, want only the archive", entries)
+	}
+}
+
+func TestVerifyEtcdSnapshotHash(t *testing.T) {
+	_, endpoint := startEtcd(t)
+	putEtcdKeys(t, endpoint)
+
+	client, err := EtcdConfig{}.newClient(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	snapshotPath := filepath.Join(t.TempDir(), "snapshot.db")
+	if _, err := saveEtcdSnapshot(context.Background(), client, snapshotPath); err != nil {
+		t.Fatal(err)
+	}
+	valid, err := os.ReadFile(snapshotPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flipped := bytes.Clone(valid)
+	flipped[5000] ^= 0x01
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{name: "valid", data: valid},
+		{name: "corrupted page", data: flipped, wantErr: "hash mismatch"},
+		{name: "corrupted hash", data: append(valid[:len(valid)-1:len(valid)-1], valid[len(valid)-1]^0x01), wantErr: "hash mismatch"},
+		{name: "no hash", data: valid[:len(valid)-sha256.Size], wantErr: "no integrity hash"},
+		{name: "truncated", data: valid[:len(valid)-1], wantErr: "no integrity hash"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "snapshot.db")
+			if err := os.WriteFile(path, tt.data, 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			hash, err := verifyEtcdSnapshotHash(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := hex.EncodeToString(tt.data[len(tt.data)-sha256.Size:]); hash != want {
+				t.Errorf("hash = %s, want %s", hash, want)
+			}
+		})
+	}
+}
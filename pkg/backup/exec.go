@@ -0,0 +1,121 @@
+package backup
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ExecBackup captures the standard output of a user-supplied command as the
+// backup payload, for data sources without a dedicated backup type
+type ExecBackup struct {
+	Name    string
+	Command string // Run with sh -c; must write the dump to stdout
+}
+
+// Validate checks if the configuration is valid
+func (eb *ExecBackup) Validate() error {
+	if strings.TrimSpace(eb.Command) == "" {
+		return fmt.Errorf("a backup command is required")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		return fmt.Errorf("sh not found in PATH: %w", err)
+	}
+	return nil
+}
+
+// Backup runs the command and streams its stdout through gzip into
+// outputPath. The command's stderr is passed through; a non-zero exit status
+// or an empty payload fails the backup.
+func (eb *ExecBackup) Backup(outputPath string) (*Result, error) {
+	startTime := time.Now()
+
+	if err := eb.Validate(); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("sh", "-c", eb.Command)
+	cmd.Stderr = os.Stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture command output: %w", err)
+	}
+
+	fmt.Printf("Running backup command...\n")
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start command: %w", err)
+	}
+
+	originalSize, copyErr := compressStream(stdout, outputPath)
+	if copyErr != nil {
+		// Unblock the command if compression failed part way
+		io.Copy(io.Discard, stdout)
+	}
+	if err := cmd.Wait(); err != nil {
+		os.Remove(outputPath)
+		return nil, fmt.Errorf("backup command failed: %w", err)
+	}
+	if copyErr != nil {
+		os.Remove(outputPath)
+		return nil, copyErr
+	}
+	if originalSize == 0 {
+		os.Remove(outputPath)
+		return nil, fmt.Errorf("backup command produced no output")
+	}
+
+	fileInfo, err := os.Stat(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat compressed file: %w", err)
+	}
+
+	result := &Result{
+		Type:         TypeExec,
+		Name:         eb.Name,
+		Filename:     filepath.Base(outputPath),
+		Path:         outputPath,
+		Size:         fileInfo.Size(),
+		OriginalSize: originalSize,
+		Duration:     time.Since(startTime),
+		Timestamp:    startTime,
+		Compression:  CompressionGzip,
+	}
+	result.CompressionPct = result.CalculateCompressionPct()
+
+	return result, nil
+}
+
+// RestoreExec decompresses a backup created by ExecBackup and feeds the
+// payload to the standard input of a restore command run with sh -c
+func RestoreExec(backupFile string, command string) error {
+	fmt.Printf("Starting restore from backup: %s\n", backupFile)
+
+	if strings.TrimSpace(command) == "" {
+		return fmt.Errorf("a restore command is required")
+	}
+
+	payload, err := decompressStream(backupFile)
+	if err != nil {
+		return err
+	}
+	defer payload.Close()
+
+	fmt.Printf("Running restore command...\n")
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = payload
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("restore command failed: %w", err)
+	}
+
+	fmt.Printf("✅ Restore completed successfully!\n")
+	fmt.Printf("   From: %s\n", backupFile)
+
+	return nil
+}
@@ -153,6 +153,7 @@ func (fb *FileBackup) Backup(outputPath string) (*Result, error) {
 
 	return &Result{
 		Type:           TypeFiles,
+		Name:           fb.Name,
 		Filename:       filepath.Base(outputPath),
 		Path:           outputPath,
 		Size:           fileInfo.Size(),
@@ -161,6 +162,7 @@ func (fb *FileBackup) Backup(outputPath string) (*Result, error) {
 		FilesIncluded:  totalFiles,
 		Timestamp:      startTime,
 		CompressionPct: compressionPct,
+		Compression:    CompressionGzip,
 	}, nil
 }
 
@@ -0,0 +1,281 @@
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scpLikeURL matches remotes written as user@host:path
+var scpLikeURL = regexp.MustCompile(`^[\w.-]+@[\w.-]+:`)
+
+// GitBackup snapshots Git repositories as verified bundles. Bundles are
+// created by git itself from a consistent set of refs, unlike a copy of a
+// live .git directory.
+type GitBackup struct {
+	Name         string
+	Repositories []string // Local repository paths or remote URLs
+}
+
+// Validate checks if the configuration is valid
+func (gb *GitBackup) Validate() error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("git not found in PATH: %w", err)
+	}
+	if len(gb.Repositories) == 0 {
+		return fmt.Errorf("at least one repository is required")
+	}
+
+	for _, repo := range gb.Repositories {
+		if isGitRemote(repo) {
+			continue
+		}
+		if _, err := runGit(repo, "rev-parse", "--git-dir"); err != nil {
+			return fmt.Errorf("not a git repository: %s", repo)
+		}
+	}
+
+	return nil
+}
+
+// Backup bundles every repository with all its refs, verifies each bundle
+// and packages them as <name>.bundle files in a tar.gz archive
+func (gb *GitBackup) Backup(outputPath string) (*Result, error) {
+	startTime := time.Now()
+
+	if err := gb.Validate(); err != nil {
+		return nil, err
+	}
+
+	tempDir, err := os.MkdirTemp(filepath.Dir(outputPath), ".git-bundles-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	refs := make(map[string]int64)
+	for _, repo := range gb.Repositories {
+		name := uniqueName(gitRepoName(repo), refs)
+
+		count, err := bundleRepository(repo, filepath.Join(tempDir, name+".bundle"), tempDir)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", redactGitURL(repo), err)
+		}
+		refs[name] = count
+		fmt.Printf("   %s: %d ref(s)\n", name, count)
+	}
+
+	originalSize, err := compressDirTarGz(tempDir, outputPath)
+	if err != nil {
+		os.Remove(outputPath)
+		return nil, err
+	}
+
+	fileInfo, err := os.Stat(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat compressed file: %w", err)
+	}
+
+	result := &Result{
+		Type:          TypeGit,
+		Name:          gb.Name,
+		Filename:      filepath.Base(outputPath),
+		Path:          outputPath,
+		Size:          fileInfo.Size(),
+		OriginalSize:  originalSize,
+		Duration:      time.Since(startTime),
+		FilesIncluded: int64(len(refs)),
+		Timestamp:     startTime,
+		Compression:   CompressionGzip,
+		Extra:         map[string]string{MetaRepositories: strconv.Itoa(len(refs))},
+	}
+	result.CompressionPct = result.CalculateCompressionPct()
+
+	return result, nil
+}
+
+// bundleRepository writes a bundle of all refs of a repository and verifies
+// it. Remote repositories are mirrored into workDir first. It returns the
+// number of refs in the bundle.
+func bundleRepository(repo string, bundlePath string, workDir string) (int64, error) {
+	source := repo
+	if isGitRemote(repo) {
+		mirror, err := os.MkdirTemp(workDir, ".mirror-*")
+		if err != nil {
+			return 0, fmt.Errorf("failed to create temp directory: %w", err)
+		}
+		defer os.RemoveAll(mirror)
+
+		fmt.Printf("Mirroring %s...\n", redactGitURL(repo))
+		if _, err := runGit("", "clone", "--mirror", "--quiet", repo, mirror); err != nil {
+			return 0, fmt.Errorf("mirror clone failed: %w", err)
+		}
+		source = mirror
+	}
+
+	fmt.Printf("Bundling %s...\n", redactGitURL(repo))
+	if _, err := runGit(source, "bundle", "create", "--quiet", bundlePath, "--all"); err != nil {
+		return 0, fmt.Errorf("bundle create failed: %w", err)
+	}
+
+	// Verification needs a repository; the source has every object the bundle refers to
+	if _, err := runGit(source, "bundle", "verify", "--quiet", bundlePath); err != nil {
+		return 0, fmt.Errorf("bundle verification failed: %w", err)
+	}
+
+	heads, err := runGit("", "bundle", "list-heads", bundlePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list bundle refs: %w", err)
+	}
+	return int64(len(strings.Split(heads, "\n"))), nil
+}
+
+// GitRestoreOptions controls how bundles are restored
+type GitRestoreOptions struct {
+	Repositories []string // Only restore these repositories (bundle names); all if empty
+}
+
+// RestoreGit clones every bundle in a backup into a bare mirror repository
+// <targetDir>/<name>.git. Existing repositories are never overwritten.
+func RestoreGit(backupFile string, targetDir string, opts GitRestoreOptions) error {
+	fmt.Printf("Starting restore from backup: %s\n", backupFile)
+
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return fmt.Errorf("failed to create target directory: %w", err)
+	}
+
+	tempDir, err := os.MkdirTemp(targetDir, ".git-restore-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	fmt.Printf("Extracting backup file...\n")
+	if _, err := extractTarGz(backupFile, tempDir); err != nil {
+		return fmt.Errorf("extraction failed: %w", err)
+	}
+
+	bundles, err := filepath.Glob(filepath.Join(tempDir, "*.bundle"))
+	if err != nil || len(bundles) == 0 {
+		return fmt.Errorf("no bundles found in archive (not a git backup?)")
+	}
+
+	var restored []string
+	for _, bundle := range bundles {
+		name := strings.TrimSuffix(filepath.Base(bundle), ".bundle")
+		if len(opts.Repositories) > 0 && !containsString(opts.Repositories, name) {
+			continue
+		}
+
+		target := filepath.Join(targetDir, name+".git")
+		if _, err := os.Stat(target); err == nil {
+			return fmt.Errorf("%s already exists; move it away or restore into another directory", target)
+		}
+
+		fmt.Printf("Restoring %s...\n", name)
+		if _, err := runGit("", "clone", "--mirror", "--quiet", bundle, target); err != nil {
+			return fmt.Errorf("%s: clone from bundle failed: %w", name, err)
+		}
+		// The bundle is deleted after the restore, so do not keep it as a remote
+		if _, err := runGit(target, "remote", "remove", "origin"); err != nil {
+			return fmt.Errorf("%s: failed to remove bundle remote: %w", name, err)
+		}
+		restored = append(restored, target)
+	}
+
+	if len(restored) == 0 {
+		return fmt.Errorf("none of the requested repositories are in the backup")
+	}
+
+	fmt.Printf("✅ Restore completed successfully!\n")
+	for _, target := range restored {
+		fmt.Printf("   Repository: %s\n", target)
+	}
+	fmt.Printf("   From: %s\n", backupFile)
+
+	return nil
+}
+
+// isGitRemote reports whether a repository is a URL rather than a local path
+func isGitRemote(repo string) bool {
+	return strings.Contains(repo, "://") || scpLikeURL.MatchString(repo)
+}
+
+// gitRepoName derives a bundle name from a repository path or URL
+func gitRepoName(repo string) string {
+	repo = strings.TrimRight(repo, "/")
+	if i := strings.LastIndexAny(repo, "/:"); i >= 0 {
+		repo = repo[i+1:]
+	}
+	repo = strings.TrimSuffix(repo, ".git")
+	if repo == "" || repo == "." {
+		return "repository"
+	}
+	return repo
+}
+
+// uniqueName returns name, or name-N if it is already taken
+func uniqueName(name string, taken map[string]int64) string {
+	if _, ok := taken[name]; !ok {
+		return name
+	}
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", name, i)
+		if _, ok := taken[candidate]; !ok {
+			return candidate
+		}
+	}
+}
+
+// redactGitURL hides credentials embedded in a remote URL for console output
+func redactGitURL(repo string) string {
+	if !strings.Contains(repo, "://") {
+		return repo
+	}
+	u, err := url.Parse(repo)
+	if err != nil {
+		return repo
+	}
+	return u.Redacted()
+}
+
+// containsString reports whether list contains value
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// runGit runs git, inside dir if it is not empty, and returns its trimmed output
+// Prompts are disabled so a missing credential fails instead of hanging.
+func runGit(dir string, args ...string) (string, error) {
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
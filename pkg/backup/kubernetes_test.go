@@ -0,0 +1,326 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatchesAny(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		name     string
+		want     bool
+	}{
+		{patterns: []string{"deployments.apps"}, name: "deployments.apps", want: true},
+		{patterns: []string{"deployments"}, name: "deployments.apps", want: true},
+		{patterns: []string{"deploy*"}, name: "deployments.apps", want: true},
+		{patterns: []string{"*.apps"}, name: "statefulsets.apps", want: true},
+		{patterns: []string{"secrets"}, name: "secrets", want: true},
+		{patterns: []string{"configmaps", "secrets"}, name: "secrets", want: true},
+		{patterns: []string{"apps"}, name: "deployments.apps", want: false},
+		{patterns: []string{"deployments.batch"}, name: "deployments.apps", want: false},
+		{patterns: []string{"secret"}, name: "secrets", want: false},
+		{patterns: nil, name: "secrets", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := matchesAny(tt.patterns, tt.name); got != tt.want {
+			t.Errorf("matchesAny(%q, %q) = %v, want %v", tt.patterns, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIncludeKind(t *testing.T) {
+	tests := []struct {
+		name     string
+		backup   KubernetesBackup
+		resource string
+		want     bool
+	}{
+		{name: "default", resource: "deployments.apps", want: true},
+		{name: "default excluded core", resource: "events", want: false},
+		{name: "default excluded group", resource: "leases.coordination.k8s.io", want: false},
+		{name: "default excluded nodes", resource: "nodes", want: false},
+		// Default exclusions match the full name, so CRDs sharing a plural survive
+		{name: "crd named nodes", resource: "nodes.cluster.example.com", want: true},
+		{name: "crd named events", resource: "events.audit.example.com", want: true},
+		{name: "crd named endpoints", resource: "endpoints.mesh.example.com", want: true},
+		{name: "crd named leases", resource: "leases.example.com", want: true},
+		{name: "kinds select", backup: KubernetesBackup{Kinds: []string{"deployments"}}, resource: "deployments.apps", want: true},
+		{name: "kinds skip", backup: KubernetesBackup{Kinds: []string{"deployments"}}, resource: "services", want: false},
+		{name: "kinds override defaults", backup: KubernetesBackup{Kinds: []string{"events"}}, resource: "events", want: true},
+		{name: "exclude short form", backup: KubernetesBackup{ExcludeKinds: []string{"nodes"}}, resource: "nodes.cluster.example.com", want: false},
+		{name: "exclude wins over kinds", backup: KubernetesBackup{Kinds: []string{"*"}, ExcludeKinds: []string{"secrets"}}, resource: "secrets", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.backup.includeKind(tt.resource); got != tt.want {
+				t.Errorf("includeKind(%q) = %v, want %v", tt.resource, got, tt.want)
+			}
+		})
+	}
+}
+
+// decodeManifest parses a JSON manifest as kubectl returns it
+func decodeManifest(t *testing.T, manifest string) map[string]interface{} {
+	t.Helper()
+
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(manifest), &object); err != nil {
+		t.Fatal(err)
+	}
+	return object
+}
+
+func TestCleanKubernetesObject(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource string
+		object   string
+		want     string // Empty if the object is skipped
+	}{
+		{
+			name:     "server fields",
+			resource: "configmaps",
+			object: `{"metadata": {"name": "app", "namespace": "shop", "uid": "1", "resourceVersion": "42",
+				"creationTimestamp": "2025-01-01T00:00:00Z", "managedFields": [],
+				"annotations": {"kubectl.kubernetes.io/last-applied-configuration": "{}"}},
+				"data": {"key": "value"}}`,
+			want: `{"metadata": {"name": "app", "namespace": "shop"}, "data": {"key": "value"}}`,
+		},
+		{
+			name:     "user annotations kept",
+			resource: "deployments.apps",
+			object: `{"metadata": {"name": "web", "generation": 3,
+				"annotations": {"deployment.kubernetes.io/revision": "3", "team": "shop"}},
+				"spec": {"replicas": 2}, "status": {"replicas": 2}}`,
+			want: `{"metadata": {"name": "web", "annotations": {"team": "shop"}}, "spec": {"replicas": 2}}`,
+		},
+		{
+			name:     "owned by a controller",
+			resource: "pods",
+			object:   `{"metadata": {"name": "web-1", "ownerReferences": [{"kind": "ReplicaSet", "controller": true}]}}`,
+		},
+		{
+			name:     "owned without a controller",
+			resource: "configmaps",
+			object:   `{"metadata": {"name": "app", "ownerReferences": [{"kind": "Application"}]}}`,
+			want:     `{"metadata": {"name": "app"}}`,
+		},
+		{
+			name:     "root ca",
+			resource: "configmaps",
+			object:   `{"metadata": {"name": "kube-root-ca.crt"}}`,
+		},
+		{
+			name:     "service account token",
+			resource: "secrets",
+			object:   `{"metadata": {"name": "default-token"}, "type": "kubernetes.io/service-account-token"}`,
+		},
+		{
+			name:     "no metadata",
+			resource: "configmaps",
+			object:   `{"data": {}}`,
+		},
+		{
+			name:     "service cluster ip",
+			resource: "services",
+			object:   `{"metadata": {"name": "web"}, "spec": {"clusterIP": "10.0.0.1", "clusterIPs": ["10.0.0.1"], "ports": []}}`,
+			want:     `{"metadata": {"name": "web"}, "spec": {"ports": []}}`,
+		},
+		{
+			name:     "headless service",
+			resource: "services",
+			object:   `{"metadata": {"name": "db"}, "spec": {"clusterIP": "None", "clusterIPs": ["None"]}}`,
+			want:     `{"metadata": {"name": "db"}, "spec": {"clusterIP": "None", "clusterIPs": ["None"]}}`,
+		},
+		{
+			name:     "claim volume",
+			resource: "persistentvolumeclaims",
+			object:   `{"metadata": {"name": "data"}, "spec": {"volumeName": "pv-1", "storageClassName": "fast"}}`,
+			want:     `{"metadata": {"name": "data"}, "spec": {"storageClassName": "fast"}}`,
+		},
+		{
+			name:     "volume claim ref",
+			resource: "persistentvolumes",
+			object:   `{"metadata": {"name": "pv-1"}, "spec": {"claimRef": {"name": "data", "uid": "1", "resourceVersion": "7"}}}`,
+			want:     `{"metadata": {"name": "pv-1"}, "spec": {"claimRef": {"name": "data"}}}`,
+		},
+		{
+			name:     "job selector",
+			resource: "jobs.batch",
+			object: `{"metadata": {"name": "migrate", "labels": {"app": "shop", "controller-uid": "1"}},
+				"spec": {"selector": {"matchLabels": {"controller-uid": "1"}},
+				"template": {"metadata": {"labels": {"app": "shop", "batch.kubernetes.io/controller-uid": "1"}}}}}`,
+			want: `{"metadata": {"name": "migrate", "labels": {"app": "shop"}},
+				"spec": {"template": {"metadata": {"labels": {"app": "shop"}}}}}`,
+		},
+		{
+			name:     "job manual selector",
+			resource: "jobs.batch",
+			object:   `{"metadata": {"name": "migrate"}, "spec": {"manualSelector": true, "selector": {"matchLabels": {"run": "x"}}}}`,
+			want:     `{"metadata": {"name": "migrate"}, "spec": {"manualSelector": true, "selector": {"matchLabels": {"run": "x"}}}}`,
+		},
+		{
+			name:     "namespace finalizers",
+			resource: "namespaces",
+			object:   `{"metadata": {"name": "shop"}, "spec": {"finalizers": ["kubernetes"]}, "status": {"phase": "Active"}}`,
+			want:     `{"metadata": {"name": "shop"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			object := decodeManifest(t, tt.object)
+			keep := cleanKubernetesObject(tt.resource, object)
+			if keep != (tt.want != "") {
+				t.Fatalf("cleanKubernetesObject = %v, want %v", keep, tt.want != "")
+			}
+			if !keep {
+				return
+			}
+			if want := decodeManifest(t, tt.want); !reflect.DeepEqual(object, want) {
+				got, _ := json.Marshal(object)
+				t.Errorf("cleaned object = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKubernetesApplyStage(t *testing.T) {
+	tests := []struct {
+		before, after string
+	}{
+		{before: "namespaces", after: "customresourcedefinitions.apiextensions.k8s.io"},
+		{before: "customresourcedefinitions.apiextensions.k8s.io", after: "serviceaccounts"},
+		{before: "serviceaccounts", after: "secrets"},
+		{before: "secrets", after: "persistentvolumeclaims"},
+		{before: "persistentvolumeclaims", after: "services"},
+		{before: "services", after: "deployments.apps"},
+		{before: "deployments.apps", after: "widgets.example.com"},
+		{before: "pods", after: "ingresses.networking.k8s.io"},
+	}
+
+	for _, tt := range tests {
+		if before, after := kubernetesApplyStage(tt.before), kubernetesApplyStage(tt.after); before >= after {
+			t.Errorf("%s applied in stage %d, not before %s in stage %d", tt.before, before, tt.after, after)
+		}
+	}
+
+	if got, want := kubernetesApplyStage("widgets.example.com"), len(kubernetesApplyOrder); got != want {
+		t.Errorf("unlisted resource applied in stage %d, want %d", got, want)
+	}
+	if got, want := kubernetesApplyStage("configmaps"), kubernetesApplyStage("secrets"); got != want {
+		t.Errorf("configmaps applied in stage %d, secrets in %d", got, want)
+	}
+}
+
+// writeTarGz writes the named files to a tar.gz archive in order
+func writeTarGz(t *testing.T, archivePath string, files [][2]string) {
+	t.Helper()
+
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for _, f := range files {
+		header := &tar.Header{Name: f[0], Mode: 0600, Size: int64(len(f[1])), Typeflag: tar.TypeReg}
+		if strings.HasSuffix(f[0], "/") {
+			header = &tar.Header{Name: f[0], Mode: 0700, Typeflag: tar.TypeDir}
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tarWriter.Write([]byte(f[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadKubernetesArchive(t *testing.T) {
+	dir := t.TempDir()
+
+	deployment := kubernetesObject{resource: "deployments.apps", scope: "shop", name: "web", data: []byte("kind: Deployment\n")}
+	namespace := kubernetesObject{resource: "namespaces", scope: clusterScope, name: "shop", data: []byte("kind: Namespace\n")}
+	archivePath := filepath.Join(dir, "cluster.tar.gz")
+	if _, err := writeKubernetesArchive([]kubernetesObject{deployment, namespace}, archivePath, time.Unix(0, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readKubernetesArchive(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// writeKubernetesArchive sorts by archive path, which puts _cluster first
+	want := []kubernetesObject{namespace, deployment}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("read %+v, want %+v", got, want)
+	}
+
+	tests := []struct {
+		name    string
+		files   [][2]string
+		wantErr string
+	}{
+		{
+			name:  "directories skipped",
+			files: [][2]string{{"shop/", ""}, {"shop/secrets/", ""}, {"shop/secrets/db.yaml", "kind: Secret\n"}},
+		},
+		{
+			name:    "postgres dump",
+			files:   [][2]string{{"shop.sql", "-- PostgreSQL database dump\n"}},
+			wantErr: "unexpected file in archive: shop.sql",
+		},
+		{
+			name:    "too deep",
+			files:   [][2]string{{"shop/secrets/extra/db.yaml", "kind: Secret\n"}},
+			wantErr: "unexpected file in archive",
+		},
+		{
+			name:    "not yaml",
+			files:   [][2]string{{"shop/secrets/db.json", "{}"}},
+			wantErr: "unexpected file in archive",
+		},
+		{
+			name:    "empty",
+			files:   [][2]string{{"shop/", ""}},
+			wantErr: "no manifests found in archive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archivePath := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "-")+".tar.gz")
+			writeTarGz(t, archivePath, tt.files)
+
+			_, err := readKubernetesArchive(archivePath)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("err = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
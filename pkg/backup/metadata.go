@@ -0,0 +1,119 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metadata keys stored with uploaded backups
+// Object stores expose them as user metadata (e.g. opc-meta-backup-type)
+const (
+	MetaType            = "backup-type"
+	MetaName            = "backup-name"
+	MetaDatabase        = "database"
+	MetaOriginalSize    = "original-size"
+	MetaCompression     = "compression"
+	MetaEncrypted       = "encrypted"
+	MetaEncryptionKeyID = "encryption-key-id"
+	MetaHostname        = "hostname"
+	MetaToolVersion     = "tool-version"
+	MetaTimestamp       = "timestamp"
+	MetaCollections     = "collections"
+	MetaDocuments       = "documents"
+	MetaRevision        = "revision"
+	MetaSnapshotHash    = "snapshot-hash"
+	MetaClusterID       = "cluster-id"
+	MetaMembers         = "members"
+	MetaObjects         = "objects"
+	MetaRepositories    = "repositories"
+	MetaDatabases       = "databases"
+	MetaStartLSN        = "start-lsn"
+	MetaTimeline        = "timeline"
+)
+
+// CompressionGzip is the compression used by the built-in backup types
+const CompressionGzip = "gzip"
+
+// metadataFileSuffix is appended to a backup file to store its metadata
+const metadataFileSuffix = ".meta.json"
+
+// Metadata returns the backup description as key/value pairs suitable for
+// storing as object metadata. Empty values are omitted.
+func (r *Result) Metadata() map[string]string {
+	meta := map[string]string{
+		MetaType:            string(r.Type),
+		MetaName:            r.Name,
+		MetaDatabase:        r.DatabaseName,
+		MetaCompression:     r.Compression,
+		MetaEncrypted:       strconv.FormatBool(r.Encrypted),
+		MetaEncryptionKeyID: r.EncryptionKeyID,
+		MetaHostname:        r.Hostname,
+		MetaToolVersion:     r.ToolVersion,
+	}
+	if r.OriginalSize > 0 {
+		meta[MetaOriginalSize] = strconv.FormatInt(r.OriginalSize, 10)
+	}
+	if !r.Timestamp.IsZero() {
+		meta[MetaTimestamp] = r.Timestamp.UTC().Format(time.RFC3339)
+	}
+
+	// Type-specific and plugin metadata never replaces the fields above
+	for key, value := range r.Extra {
+		key = strings.ToLower(key)
+		if _, ok := meta[key]; !ok {
+			meta[key] = value
+		}
+	}
+
+	for key, value := range meta {
+		if value == "" {
+			delete(meta, key)
+		}
+	}
+
+	return meta
+}
+
+// MetadataFilePath returns the path of the metadata file written next to a
+// backup file
+func MetadataFilePath(backupPath string) string {
+	return backupPath + metadataFileSuffix
+}
+
+// WriteMetadataFile stores metadata next to the backup file so it can be
+// attached when the file is uploaded later
+func WriteMetadataFile(backupPath string, meta map[string]string) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %w", err)
+	}
+
+	if err := os.WriteFile(MetadataFilePath(backupPath), data, 0644); err != nil {
+		return fmt.Errorf("failed to write metadata file: %w", err)
+	}
+
+	return nil
+}
+
+// ReadMetadataFile loads the metadata written for a backup file
+// It returns nil without error if the backup has no metadata file
+func ReadMetadataFile(backupPath string) (map[string]string, error) {
+	data, err := os.ReadFile(MetadataFilePath(backupPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata file: %w", err)
+	}
+
+	var meta map[string]string
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata file: %w", err)
+	}
+
+	return meta, nil
+}
@@ -0,0 +1,39 @@
+package backup
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestResultMetadata(t *testing.T) {
+	result := &Result{
+		Type:         TypeEtcd,
+		Name:         "k8s-etcd",
+		DatabaseName: "https://127.0.0.1:2379",
+		OriginalSize: 2048,
+		Compression:  CompressionGzip,
+		Timestamp:    time.Date(2025, 12, 9, 9, 26, 58, 0, time.FixedZone("CET", 3600)),
+		Extra: map[string]string{
+			MetaRevision:     "1234",
+			MetaSnapshotHash: "0badf00d",
+			MetaMembers:      "",
+			"Backup-Type":    "spoofed",
+		},
+	}
+
+	want := map[string]string{
+		MetaType:         "etcd",
+		MetaName:         "k8s-etcd",
+		MetaDatabase:     "https://127.0.0.1:2379",
+		MetaOriginalSize: "2048",
+		MetaCompression:  "gzip",
+		MetaEncrypted:    "false",
+		MetaTimestamp:    "2025-12-09T08:26:58Z",
+		MetaRevision:     "1234",
+		MetaSnapshotHash: "0badf00d",
+	}
+	if got := result.Metadata(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Metadata() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,282 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MySQLConfig holds the connection settings for a MySQL or MariaDB server
+type MySQLConfig struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Database string // Single database to dump or restore into
+}
+
+// MySQLBackup dumps MySQL/MariaDB databases with mysqldump
+type MySQLBackup struct {
+	Name         string
+	Config       MySQLConfig
+	Databases    []string // Databases to dump; Config.Database is used if empty
+	AllDatabases bool     // Dump every database on the server
+}
+
+// Validate checks if the configuration is valid
+func (mb *MySQLBackup) Validate() error {
+	if _, err := exec.LookPath("mysqldump"); err != nil {
+		return fmt.Errorf("mysqldump not found in PATH: %w", err)
+	}
+
+	databases := mb.databases()
+	if mb.AllDatabases && len(databases) > 0 {
+		return fmt.Errorf("cannot combine a database list with all databases")
+	}
+	if !mb.AllDatabases && len(databases) == 0 {
+		return fmt.Errorf("no database specified for backup")
+	}
+
+	return nil
+}
+
+// Backup dumps the configured databases and compresses the dump to outputPath
+// The dump is taken in a single transaction, so InnoDB tables are consistent
+// without locking, and includes routines, triggers and events.
+func (mb *MySQLBackup) Backup(outputPath string) (*Result, error) {
+	startTime := time.Now()
+
+	if err := mb.Validate(); err != nil {
+		return nil, err
+	}
+
+	dumpPath := strings.TrimSuffix(outputPath, ".tar.gz") + ".sql"
+
+	fmt.Printf("Dumping MySQL database(s) '%s'...\n", mb.databaseLabel())
+	if err := runMySQLDump(mb.Config, mb.dumpArgs(), dumpPath); err != nil {
+		os.Remove(dumpPath)
+		return nil, fmt.Errorf("mysqldump failed: %w", err)
+	}
+	defer os.Remove(dumpPath)
+
+	dumpInfo, err := os.Stat(dumpPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat dump file: %w", err)
+	}
+
+	if err := compressTarGz(dumpPath, outputPath); err != nil {
+		os.Remove(outputPath)
+		return nil, fmt.Errorf("compression failed: %w", err)
+	}
+
+	fileInfo, err := os.Stat(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat compressed file: %w", err)
+	}
+
+	result := &Result{
+		Type:         TypeMySQL,
+		Name:         mb.Name,
+		Filename:     filepath.Base(outputPath),
+		Path:         outputPath,
+		Size:         fileInfo.Size(),
+		OriginalSize: dumpInfo.Size(),
+		Duration:     time.Since(startTime),
+		DatabaseName: mb.databaseLabel(),
+		Timestamp:    startTime,
+		Compression:  CompressionGzip,
+	}
+	result.CompressionPct = result.CalculateCompressionPct()
+
+	return result, nil
+}
+
+// databases returns the databases to dump
+func (mb *MySQLBackup) databases() []string {
+	if len(mb.Databases) > 0 {
+		return mb.Databases
+	}
+	if mb.Config.Database != "" {
+		return []string{mb.Config.Database}
+	}
+	return nil
+}
+
+// databaseLabel describes the dumped databases for the backup result
+func (mb *MySQLBackup) databaseLabel() string {
+	if mb.AllDatabases {
+		return "all"
+	}
+	return strings.Join(mb.databases(), ",")
+}
+
+// dumpArgs builds the mysqldump arguments selecting what to dump
+// A single database is dumped without CREATE DATABASE/USE statements so it can
+// be restored under a different name; several databases keep their names.
+func (mb *MySQLBackup) dumpArgs() []string {
+	args := []string{
+		"--single-transaction",
+		"--quick",
+		"--routines",
+		"--triggers",
+		"--events",
+		"--hex-blob",
+	}
+
+	databases := mb.databases()
+	switch {
+	case mb.AllDatabases:
+		args = append(args, "--all-databases")
+	case len(databases) == 1:
+		args = append(args, databases[0])
+	default:
+		args = append(args, "--databases")
+		args = append(args, databases...)
+	}
+
+	return args
+}
+
+// runMySQLDump executes mysqldump writing the dump to outputPath
+func runMySQLDump(config MySQLConfig, args []string, outputPath string) error {
+	optionFile, err := writeMySQLOptionFile(config)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(optionFile)
+
+	// --defaults-extra-file must be the first argument
+	args = append([]string{"--defaults-extra-file=" + optionFile, "--result-file=" + outputPath}, args...)
+
+	cmd := exec.Command("mysqldump", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+// RestoreMySQL restores a MySQL/MariaDB dump from a .tar.gz backup
+// Single-database dumps are restored into targetDB or config.Database, which
+// is created if missing. Multi-database dumps recreate their own databases
+// and are restored with both left empty.
+func RestoreMySQL(config MySQLConfig, backupFile string, targetDB string) error {
+	fmt.Printf("Starting restore from backup: %s\n", backupFile)
+
+	// Create temporary directory for extraction
+	tempDir, err := os.MkdirTemp("", "mysql-restore-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Step 1: Extract .tar.gz
+	fmt.Printf("Extracting backup file...\n")
+	sqlFile, err := extractTarGz(backupFile, tempDir)
+	if err != nil {
+		return fmt.Errorf("extraction failed: %w", err)
+	}
+
+	// Override target database if specified
+	if targetDB != "" {
+		config.Database = targetDB
+	}
+
+	optionFile, err := writeMySQLOptionFile(config)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(optionFile)
+
+	// Step 2: Make sure the target database exists
+	if config.Database != "" {
+		create := fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s", quoteMySQLIdentifier(config.Database))
+		cmd := exec.Command("mysql", "--defaults-extra-file="+optionFile, "-e", create)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to create database %s: %w", config.Database, err)
+		}
+	}
+
+	// Step 3: Feed the dump to the mysql client
+	fmt.Printf("Restoring to database '%s'...\n", restoreLabel(config.Database))
+	input, err := os.Open(sqlFile)
+	if err != nil {
+		return fmt.Errorf("failed to open dump file: %w", err)
+	}
+	defer input.Close()
+
+	args := []string{"--defaults-extra-file=" + optionFile}
+	if config.Database != "" {
+		args = append(args, config.Database)
+	}
+
+	cmd := exec.Command("mysql", args...)
+	cmd.Stdin = input
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("restore failed: %w", err)
+	}
+
+	fmt.Printf("✅ Restore completed successfully!\n")
+	fmt.Printf("   Database: %s\n", restoreLabel(config.Database))
+	fmt.Printf("   From: %s\n", backupFile)
+
+	return nil
+}
+
+// restoreLabel describes the restore target for console output
+func restoreLabel(database string) string {
+	if database == "" {
+		return "(databases from dump)"
+	}
+	return database
+}
+
+// writeMySQLOptionFile writes the connection settings to a private option
+// file so the password never appears in the process list or environment
+func writeMySQLOptionFile(config MySQLConfig) (string, error) {
+	file, err := os.CreateTemp("", "orchestrator-mysql-*.cnf")
+	if err != nil {
+		return "", fmt.Errorf("failed to create MySQL option file: %w", err)
+	}
+	defer file.Close()
+
+	var b strings.Builder
+	b.WriteString("[client]\n")
+	if config.Host != "" {
+		fmt.Fprintf(&b, "host=%s\n", quoteMySQLOption(config.Host))
+	}
+	if config.Port > 0 {
+		fmt.Fprintf(&b, "port=%d\n", config.Port)
+	}
+	if config.User != "" {
+		fmt.Fprintf(&b, "user=%s\n", quoteMySQLOption(config.User))
+	}
+	if config.Password != "" {
+		fmt.Fprintf(&b, "password=%s\n", quoteMySQLOption(config.Password))
+	}
+
+	if _, err := file.WriteString(b.String()); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write MySQL option file: %w", err)
+	}
+
+	return file.Name(), nil
+}
+
+// quoteMySQLOption quotes a value for a MySQL option file
+func quoteMySQLOption(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
+
+// quoteMySQLIdentifier quotes a database name for use in SQL
+func quoteMySQLIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
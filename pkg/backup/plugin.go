@@ -0,0 +1,361 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PluginPrefix is the executable name prefix of backup type plugins
+// An executable orchestrator-backup-<type> on PATH provides the type <type>.
+const PluginPrefix = "orchestrator-backup-"
+
+// PluginProtocolVersion is the plugin protocol version spoken by this build
+const PluginProtocolVersion = 1
+
+// Plugin commands, passed as the first argument to the plugin
+const (
+	PluginCommandDescribe = "describe"
+	PluginCommandValidate = "validate"
+	PluginCommandBackup   = "backup"
+	PluginCommandRestore  = "restore"
+)
+
+// describeTimeout bounds the describe and validate calls
+const describeTimeout = 30 * time.Second
+
+// Plugin is a discovered backup type plugin
+type Plugin struct {
+	Type string // Backup type, the executable name without PluginPrefix
+	Path string // Absolute path of the executable
+	PluginDescription
+}
+
+// PluginDescription is the plugin's reply to the describe command
+type PluginDescription struct {
+	ProtocolVersion int            `json:"protocol_version"`
+	Description     string         `json:"description"`
+	Capabilities    []string       `json:"capabilities"` // Commands besides describe the plugin implements
+	Options         []PluginOption `json:"options,omitempty"`
+}
+
+// PluginOption documents an option the plugin accepts
+type PluginOption struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// pluginRequest is written as a single JSON line to the plugin's stdin
+type pluginRequest struct {
+	ProtocolVersion int               `json:"protocol_version"`
+	Command         string            `json:"command"`
+	Name            string            `json:"name,omitempty"`
+	Options         map[string]string `json:"options"`
+}
+
+// pluginValidation is the plugin's reply to the validate command
+type pluginValidation struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// pluginBackupResult is the optional reply a plugin writes to file
+// descriptor 3 after a backup
+type pluginBackupResult struct {
+	Database string            `json:"database,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Supports reports whether the plugin implements a command
+func (p *Plugin) Supports(command string) bool {
+	return command == PluginCommandDescribe || containsString(p.Capabilities, command)
+}
+
+// DiscoverPlugins returns the plugins found on PATH, sorted by type. When
+// several directories provide the same type, the first one on PATH wins, as
+// it would for a shell. Plugins that fail to describe themselves are skipped.
+func DiscoverPlugins() []*Plugin {
+	seen := make(map[string]bool)
+	var plugins []*Plugin
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			pluginType := strings.TrimPrefix(name, PluginPrefix)
+			if !strings.HasPrefix(name, PluginPrefix) || pluginType == "" || seen[pluginType] {
+				continue
+			}
+			seen[pluginType] = true
+
+			plugin, err := LoadPlugin(pluginType)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "⚠️  Skipping plugin %s: %v\n", name, err)
+				continue
+			}
+			plugins = append(plugins, plugin)
+		}
+	}
+
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Type < plugins[j].Type
+	})
+	return plugins
+}
+
+// LoadPlugin finds the plugin for a backup type on PATH and negotiates the
+// protocol version with it
+func LoadPlugin(pluginType string) (*Plugin, error) {
+	path, err := exec.LookPath(PluginPrefix + pluginType)
+	if err != nil {
+		return nil, fmt.Errorf("no plugin for backup type %q on PATH: %w", pluginType, err)
+	}
+
+	plugin := &Plugin{Type: pluginType, Path: path}
+
+	output, err := plugin.call(PluginCommandDescribe, nil)
+	if err != nil {
+		return nil, fmt.Errorf("describe failed: %w", err)
+	}
+	if err := json.Unmarshal(output, &plugin.PluginDescription); err != nil {
+		return nil, fmt.Errorf("invalid describe reply: %w", err)
+	}
+	if plugin.ProtocolVersion != PluginProtocolVersion {
+		return nil, fmt.Errorf("plugin speaks protocol version %d, this orchestrator supports version %d", plugin.ProtocolVersion, PluginProtocolVersion)
+	}
+
+	return plugin, nil
+}
+
+// call runs a short plugin command that replies with JSON on stdout
+func (p *Plugin) call(command string, request *pluginRequest) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), describeTimeout)
+	defer cancel()
+
+	cmd := p.command(ctx, command)
+	if request != nil {
+		line, err := encodePluginRequest(request)
+		if err != nil {
+			return nil, err
+		}
+		cmd.Stdin = bytes.NewReader(line)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// command prepares an invocation of the plugin
+func (p *Plugin) command(ctx context.Context, command string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, p.Path, command)
+	cmd.Env = append(os.Environ(), "ORCHESTRATOR_PROTOCOL_VERSION="+strconv.Itoa(PluginProtocolVersion))
+	return cmd
+}
+
+// encodePluginRequest encodes a request as a single newline-terminated line
+func encodePluginRequest(request *pluginRequest) ([]byte, error) {
+	request.ProtocolVersion = PluginProtocolVersion
+	if request.Options == nil {
+		request.Options = map[string]string{}
+	}
+
+	line, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode plugin request: %w", err)
+	}
+	return append(line, '\n'), nil
+}
+
+// PluginBackup runs a backup through an external plugin. The plugin writes
+// the payload to stdout, which is gzip-compressed like an exec backup.
+type PluginBackup struct {
+	Name    string
+	Plugin  *Plugin
+	Options map[string]string // Plugin-specific options
+}
+
+// Validate checks the options locally and, if the plugin supports it, asks
+// the plugin to check them too
+func (pb *PluginBackup) Validate() error {
+	if pb.Plugin == nil {
+		return fmt.Errorf("no plugin given")
+	}
+	if !pb.Plugin.Supports(PluginCommandBackup) {
+		return fmt.Errorf("plugin %s does not support backups", pb.Plugin.Type)
+	}
+	for _, option := range pb.Plugin.Options {
+		if option.Required && pb.Options[option.Name] == "" {
+			return fmt.Errorf("plugin %s requires option %q (%s)", pb.Plugin.Type, option.Name, option.Description)
+		}
+	}
+
+	if !pb.Plugin.Supports(PluginCommandValidate) {
+		return nil
+	}
+
+	output, err := pb.Plugin.call(PluginCommandValidate, &pluginRequest{Command: PluginCommandValidate, Name: pb.Name, Options: pb.Options})
+	if err != nil {
+		return fmt.Errorf("plugin validation failed: %w", err)
+	}
+
+	var validation pluginValidation
+	if err := json.Unmarshal(output, &validation); err != nil {
+		return fmt.Errorf("invalid validate reply: %w", err)
+	}
+	if !validation.OK {
+		return fmt.Errorf("%s", validation.Error)
+	}
+	return nil
+}
+
+// Backup runs the plugin's backup command and streams its stdout through
+// gzip into outputPath
+func (pb *PluginBackup) Backup(outputPath string) (*Result, error) {
+	startTime := time.Now()
+
+	if err := pb.Validate(); err != nil {
+		return nil, err
+	}
+
+	line, err := encodePluginRequest(&pluginRequest{Command: PluginCommandBackup, Name: pb.Name, Options: pb.Options})
+	if err != nil {
+		return nil, err
+	}
+
+	// The plugin may describe the backup on file descriptor 3
+	replyReader, replyWriter, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reply pipe: %w", err)
+	}
+	defer replyReader.Close()
+
+	cmd := pb.Plugin.command(context.Background(), PluginCommandBackup)
+	cmd.Stdin = bytes.NewReader(line)
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = []*os.File{replyWriter}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		replyWriter.Close()
+		return nil, fmt.Errorf("failed to capture plugin output: %w", err)
+	}
+
+	fmt.Printf("Running %s plugin...\n", pb.Plugin.Type)
+	if err := cmd.Start(); err != nil {
+		replyWriter.Close()
+		return nil, fmt.Errorf("failed to start plugin: %w", err)
+	}
+	replyWriter.Close()
+
+	reply := make(chan []byte, 1)
+	go func() {
+		data, _ := io.ReadAll(replyReader)
+		reply <- data
+	}()
+
+	originalSize, copyErr := compressStream(stdout, outputPath)
+	if copyErr != nil {
+		// Unblock the plugin if compression failed part way
+		io.Copy(io.Discard, stdout)
+	}
+	if err := cmd.Wait(); err != nil {
+		os.Remove(outputPath)
+		return nil, fmt.Errorf("plugin backup failed: %w", err)
+	}
+	if copyErr != nil {
+		os.Remove(outputPath)
+		return nil, copyErr
+	}
+	if originalSize == 0 {
+		os.Remove(outputPath)
+		return nil, fmt.Errorf("plugin produced no output")
+	}
+
+	var described pluginBackupResult
+	if data := bytes.TrimSpace(<-reply); len(data) > 0 {
+		if err := json.Unmarshal(data, &described); err != nil {
+			os.Remove(outputPath)
+			return nil, fmt.Errorf("invalid backup reply from plugin: %w", err)
+		}
+	}
+
+	fileInfo, err := os.Stat(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat compressed file: %w", err)
+	}
+
+	result := &Result{
+		Type:         BackupType(pb.Plugin.Type),
+		Name:         pb.Name,
+		Filename:     filepath.Base(outputPath),
+		Path:         outputPath,
+		Size:         fileInfo.Size(),
+		OriginalSize: originalSize,
+		Duration:     time.Since(startTime),
+		DatabaseName: described.Database,
+		Timestamp:    startTime,
+		Compression:  CompressionGzip,
+		Extra:        described.Metadata,
+	}
+	result.CompressionPct = result.CalculateCompressionPct()
+
+	return result, nil
+}
+
+// RestorePlugin decompresses a plugin backup and streams it to the plugin's
+// restore command, after the request line on stdin
+func RestorePlugin(plugin *Plugin, backupFile string, options map[string]string) error {
+	fmt.Printf("Starting restore from backup: %s\n", backupFile)
+
+	if !plugin.Supports(PluginCommandRestore) {
+		return fmt.Errorf("plugin %s does not support restores", plugin.Type)
+	}
+
+	line, err := encodePluginRequest(&pluginRequest{Command: PluginCommandRestore, Options: options})
+	if err != nil {
+		return err
+	}
+
+	payload, err := decompressStream(backupFile)
+	if err != nil {
+		return err
+	}
+	defer payload.Close()
+
+	fmt.Printf("Running %s plugin...\n", plugin.Type)
+	cmd := plugin.command(context.Background(), PluginCommandRestore)
+	cmd.Stdin = io.MultiReader(bytes.NewReader(line), payload)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("plugin restore failed: %w", err)
+	}
+
+	fmt.Printf("✅ Restore completed successfully!\n")
+	fmt.Printf("   From: %s\n", backupFile)
+
+	return nil
+}
@@ -0,0 +1,254 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// usePluginDirs replaces PATH with the given directories, followed only by
+// the system directories the plugin scripts need for sed and cat, so plugins
+// installed on the machine are not picked up
+func usePluginDirs(t *testing.T, dirs ...string) {
+	t.Helper()
+
+	absolute := make([]string, 0, len(dirs)+2)
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		absolute = append(absolute, abs)
+	}
+	t.Setenv("PATH", strings.Join(append(absolute, "/usr/bin", "/bin"), string(os.PathListSeparator)))
+}
+
+// writePlugin writes a shell script plugin of the given type into dir
+func writePlugin(t *testing.T, dir, pluginType, script string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, PluginPrefix+pluginType), []byte("#!/bin/sh\n"+script), 0700); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// describeScript is a plugin that only answers describe
+func describeScript(version, description string) string {
+	return `echo '{"protocol_version": ` + version + `, "description": "` + description + `", "capabilities": []}'` + "\n"
+}
+
+func TestLoadPlugin(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "garbled", "echo 'not json'\n")
+	writePlugin(t, dir, "crashing", "echo 'cannot read config' >&2\nexit 1\n")
+	usePluginDirs(t, filepath.Join("testdata", "plugins"), dir)
+
+	plugin, err := LoadPlugin("notes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plugin.Type != "notes" || plugin.ProtocolVersion != PluginProtocolVersion || plugin.Description != "Notes file" {
+		t.Errorf("loaded %+v", plugin)
+	}
+	if !filepath.IsAbs(plugin.Path) {
+		t.Errorf("Path = %s, want an absolute path", plugin.Path)
+	}
+	for _, command := range []string{PluginCommandDescribe, PluginCommandValidate, PluginCommandBackup, PluginCommandRestore} {
+		if !plugin.Supports(command) {
+			t.Errorf("plugin does not support %s", command)
+		}
+	}
+	if len(plugin.Options) != 1 || plugin.Options[0].Name != "file" || !plugin.Options[0].Required {
+		t.Errorf("Options = %+v, want the required file option", plugin.Options)
+	}
+
+	tests := []struct {
+		name       string
+		pluginType string
+		version    string
+		wantErr    string
+	}{
+		{name: "newer protocol", pluginType: "notes", version: "2", wantErr: "plugin speaks protocol version 2, this orchestrator supports version 1"},
+		{name: "older protocol", pluginType: "notes", version: "0", wantErr: "plugin speaks protocol version 0"},
+		{name: "invalid reply", pluginType: "garbled", wantErr: "invalid describe reply"},
+		{name: "describe fails", pluginType: "crashing", wantErr: "describe failed: exit status 1: cannot read config"},
+		{name: "not installed", pluginType: "ldap", wantErr: `no plugin for backup type "ldap" on PATH`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NOTES_PROTOCOL_VERSION", tt.version)
+
+			_, err := LoadPlugin(tt.pluginType)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPluginBackupValidate(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "restoreonly", `echo '{"protocol_version": 1, "capabilities": ["restore"]}'`+"\n")
+	usePluginDirs(t, filepath.Join("testdata", "plugins"), dir)
+
+	notes, err := LoadPlugin("notes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	restoreOnly, err := LoadPlugin("restoreonly")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notesFile := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notesFile, []byte("buy milk\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		backup  PluginBackup
+		wantErr string
+	}{
+		{name: "valid", backup: PluginBackup{Name: "notes", Plugin: notes, Options: map[string]string{"file": notesFile}}},
+		{name: "no plugin", backup: PluginBackup{Name: "notes"}, wantErr: "no plugin given"},
+		{name: "no backup capability", backup: PluginBackup{Name: "notes", Plugin: restoreOnly}, wantErr: "plugin restoreonly does not support backups"},
+		{name: "required option", backup: PluginBackup{Name: "notes", Plugin: notes}, wantErr: `plugin notes requires option "file" (Notes file to back up or restore)`},
+		{
+			name:    "rejected by the plugin",
+			backup:  PluginBackup{Name: "notes", Plugin: notes, Options: map[string]string{"file": filepath.Join(dir, "missing.txt")}},
+			wantErr: "no notes file at " + filepath.Join(dir, "missing.txt"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.backup.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("err = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPluginRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	usePluginDirs(t, filepath.Join("testdata", "plugins"))
+
+	plugin, err := LoadPlugin("notes")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notes := "buy milk\nwater the plants\n"
+	notesFile := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notesFile, []byte(notes), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	backupPath := filepath.Join(dir, "notes.gz")
+	pb := &PluginBackup{Name: "daily-notes", Plugin: plugin, Options: map[string]string{"file": notesFile}}
+	result, err := pb.Backup(backupPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Type != "notes" || result.Name != "daily-notes" || result.OriginalSize != int64(len(notes)) {
+		t.Errorf("result = %+v", result)
+	}
+	// The reply on file descriptor 3 describes the backup
+	if result.DatabaseName != notesFile {
+		t.Errorf("DatabaseName = %q, want %q", result.DatabaseName, notesFile)
+	}
+	if got := result.Extra["backup_name"]; got != "daily-notes" {
+		t.Errorf("metadata backup_name = %q, want daily-notes", got)
+	}
+
+	// The plugin reads the request line, then exactly the payload
+	restored := filepath.Join(dir, "restored.txt")
+	if err := RestorePlugin(plugin, backupPath, map[string]string{"file": restored}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != notes {
+		t.Errorf("restored %q, want %q", data, notes)
+	}
+}
+
+func TestPluginBackupWithoutReply(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "quiet", `case "$1" in
+describe) echo '{"protocol_version": 1, "capabilities": ["backup"]}' ;;
+backup) cat >/dev/null; echo payload ;;
+esac
+`)
+	writePlugin(t, dir, "garbled", `case "$1" in
+describe) echo '{"protocol_version": 1, "capabilities": ["backup"]}' ;;
+backup) cat >/dev/null; echo payload; echo 'not json' >&3 ;;
+esac
+`)
+	usePluginDirs(t, dir)
+
+	quiet, err := LoadPlugin("quiet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := (&PluginBackup{Name: "quiet", Plugin: quiet}).Backup(filepath.Join(dir, "quiet.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.DatabaseName != "" || len(result.Extra) != 0 {
+		t.Errorf("result without a reply = %+v", result)
+	}
+
+	garbled, err := LoadPlugin("garbled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	garbledPath := filepath.Join(dir, "garbled.gz")
+	_, err = (&PluginBackup{Name: "garbled", Plugin: garbled}).Backup(garbledPath)
+	if err == nil || !strings.Contains(err.Error(), "invalid backup reply from plugin") {
+		t.Errorf("err = %v, want an invalid reply", err)
+	}
+	if _, err := os.Stat(garbledPath); !os.IsNotExist(err) {
+		t.Errorf("%s kept after an invalid reply", garbledPath)
+	}
+}
+
+func TestDiscoverPlugins(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	writePlugin(t, first, "ldap", describeScript("1", "first ldap"))
+	writePlugin(t, second, "ldap", describeScript("1", "second ldap"))
+	writePlugin(t, second, "vault", describeScript("1", "vault"))
+	writePlugin(t, second, "future", describeScript("2", "future"))
+	// An incompatible plugin shadows a working one later on PATH, as it would for a shell
+	writePlugin(t, first, "consul", describeScript("2", "first consul"))
+	writePlugin(t, second, "consul", describeScript("1", "second consul"))
+	writePlugin(t, second, "", describeScript("1", "no type"))
+	usePluginDirs(t, first, second, filepath.Join(first, "missing"))
+
+	plugins := DiscoverPlugins()
+
+	var got []string
+	for _, plugin := range plugins {
+		got = append(got, plugin.Type+": "+plugin.Description)
+	}
+	want := []string{"ldap: first ldap", "vault: vault"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("discovered %q, want %q", got, want)
+	}
+	if len(plugins) > 0 && filepath.Dir(plugins[0].Path) != first {
+		t.Errorf("ldap loaded from %s, want %s", plugins[0].Path, first)
+	}
+}
@@ -2,15 +2,21 @@ package backup
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// PostgresConfig holds the connection settings for a PostgreSQL server
 type PostgresConfig struct {
 	Host     string
 	Port     int
@@ -19,6 +25,9 @@ type PostgresConfig struct {
 	Database string
 }
 
+// BackupResult is the result returned by DumpPostgres
+//
+// Deprecated: use PostgresBackup, which returns a Result.
 type BackupResult struct {
 	FilePath       string
 	OriginalSize   int64
@@ -26,96 +35,335 @@ type BackupResult struct {
 	Duration       time.Duration
 }
 
-// DumpPostgres creates a PostgreSQL dump and compresses it to .tar.gz
-func DumpPostgres(config PostgresConfig, backupName string, outputDir string) (*BackupResult, error) {
-	startTime := time.Now()
+// pg_dump archive formats
+const (
+	// PgFormatPlain is an SQL script replayed with psql
+	PgFormatPlain = "plain"
+	// PgFormatCustom is a single compressed archive restored with pg_restore
+	PgFormatCustom = "custom"
+	// PgFormatDirectory is one file per table, dumped and restored in parallel
+	PgFormatDirectory = "directory"
+)
 
-	// Create output directory if it doesn't exist
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create output directory: %w", err)
+// pgCustomMagic is the header every custom format archive starts with
+const pgCustomMagic = "PGDMP"
+
+// pgPlainMagic is the header every plain format dump of a database starts with
+const pgPlainMagic = "--\n-- PostgreSQL database dump"
+
+// errPgPlainFilters is returned when a plain dump is restored selectively
+var errPgPlainFilters = errors.New("schema and table filters need a custom or directory format backup; plain dumps can only be restored whole (take backups in the custom format to restore parts of them)")
+
+// pgVersionPattern matches the version in `pg_dump --version` output
+var pgVersionPattern = regexp.MustCompile(`\) (\d+)(?:\.(\d+))?`)
+
+// PostgresBackup dumps a PostgreSQL database with pg_dump. It prints nothing
+// itself, so it can be used as a library.
+// With AllDatabases or Databases set it backs up a whole server instead: the
+// matching databases are discovered and dumped one by one, together with the
+// roles and tablespaces from pg_dumpall --globals-only.
+// Schemas, Tables and ExcludeTables take pg_dump patterns and narrow a single
+// database dump to part of it.
+type PostgresBackup struct {
+	Name             string
+	Config           PostgresConfig
+	Format           string    // PgFormatPlain (default), PgFormatCustom or PgFormatDirectory
+	Jobs             int       // Parallel pg_dump workers, directory format only
+	AllDatabases     bool      // Dump every database on the server plus globals
+	Databases        []string  // Databases to dump plus globals, glob patterns allowed
+	ExcludeDatabases []string  // Databases to skip in a server backup, glob patterns allowed
+	Schemas          []string  // Only dump these schemas
+	Tables           []string  // Only dump these tables, optionally schema-qualified
+	ExcludeTables    []string  // Tables to leave out of the dump
+	Progress         io.Writer // Receives pg_dump's verbose progress output; discarded if nil
+}
+
+// Validate checks that pg_dump is installed, the server is reachable with
+// the configured credentials, and pg_dump is not older than the server
+func (pb *PostgresBackup) Validate() error {
+	if pb.Config.Database == "" && !pb.cluster() {
+		return fmt.Errorf("database name is required")
+	}
+	if pb.AllDatabases && len(pb.Databases) > 0 {
+		return fmt.Errorf("cannot combine a database list with all databases")
+	}
+	if pb.cluster() && pb.format() == PgFormatDirectory {
+		return fmt.Errorf("the %s format is not supported for server backups", PgFormatDirectory)
+	}
+	if pb.cluster() && (len(pb.Schemas) > 0 || len(pb.Tables) > 0 || len(pb.ExcludeTables) > 0) {
+		return fmt.Errorf("schema and table filters are not supported for server backups")
+	}
+	// pg_dump ignores schema filters once tables are selected
+	if len(pb.Schemas) > 0 && len(pb.Tables) > 0 {
+		return fmt.Errorf("cannot combine schema and table filters; qualify the tables with their schema instead")
+	}
+	switch pb.format() {
+	case PgFormatPlain, PgFormatCustom, PgFormatDirectory:
+	default:
+		return fmt.Errorf("unsupported pg_dump format: %s (supported: %s, %s, %s)", pb.Format, PgFormatPlain, PgFormatCustom, PgFormatDirectory)
+	}
+	// pg_dump only parallelizes directory format dumps
+	if pb.Jobs > 1 && pb.format() != PgFormatDirectory {
+		return fmt.Errorf("parallel dumps require the %s format", PgFormatDirectory)
+	}
+	tools := []string{"pg_dump", "psql"}
+	if pb.cluster() {
+		tools = append(tools, "pg_dumpall")
+	}
+	for _, tool := range tools {
+		if _, err := exec.LookPath(tool); err != nil {
+			return fmt.Errorf("%s not found in PATH: %w", tool, err)
+		}
 	}
 
-	// Generate filenames
-	timestamp := time.Now().Format("20060102-150405")
-	dumpFileName := fmt.Sprintf("%s-%s.sql", backupName, timestamp)
-	dumpFilePath := filepath.Join(outputDir, dumpFileName)
-	tarGzFileName := fmt.Sprintf("%s-%s.tar.gz", backupName, timestamp)
-	tarGzFilePath := filepath.Join(outputDir, tarGzFileName)
-
-	// Step 1: Run pg_dump
-	fmt.Printf("Dumping PostgreSQL database '%s'...\n", config.Database)
-	if err := runPgDump(config, dumpFilePath); err != nil {
-		return nil, fmt.Errorf("pg_dump failed: %w", err)
+	output, err := exec.Command("pg_dump", "--version").Output()
+	if err != nil {
+		return fmt.Errorf("failed to get pg_dump version: %w", err)
+	}
+	clientVersion, err := parsePgDumpVersion(string(output))
+	if err != nil {
+		return err
 	}
 
-	// Get original size
-	fileInfo, err := os.Stat(dumpFilePath)
+	reply, err := runPsqlQuery(pb.connectConfig(), "SHOW server_version_num")
 	if err != nil {
-		return nil, fmt.Errorf("failed to stat dump file: %w", err)
+		return fmt.Errorf("cannot connect to PostgreSQL at %s:%d: %w", pb.Config.Host, pb.Config.Port, err)
 	}
-	originalSize := fileInfo.Size()
-	fmt.Printf("Dump created: %s (%.2f MB)\n", dumpFilePath, float64(originalSize)/1024/1024)
+	serverVersion, err := strconv.Atoi(reply)
+	if err != nil {
+		return fmt.Errorf("unexpected server version: %s", reply)
+	}
+
+	return checkPgDumpVersion(clientVersion, serverVersion)
+}
 
-	// Step 2: Compress to .tar.gz
-	fmt.Printf("Compressing to %s...\n", tarGzFileName)
-	if err := compressTarGz(dumpFilePath, tarGzFilePath); err != nil {
-		os.Remove(dumpFilePath) // Cleanup
+// checkPgDumpVersion compares server_version_num values, since pg_dump
+// refuses to dump servers of a newer major version
+func checkPgDumpVersion(clientVersion, serverVersion int) error {
+	if clientVersion/100 < serverVersion/100 {
+		return fmt.Errorf("pg_dump %s is older than the server (%s); install a newer PostgreSQL client",
+			formatPgVersion(clientVersion), formatPgVersion(serverVersion))
+	}
+	return nil
+}
+
+// Backup dumps the database and compresses the dump to outputPath
+func (pb *PostgresBackup) Backup(outputPath string) (*Result, error) {
+	startTime := time.Now()
+
+	err := pb.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if pb.cluster() {
+		return pb.backupCluster(outputPath, startTime)
+	}
+
+	dumpPath := strings.TrimSuffix(outputPath, ".tar.gz")
+	switch pb.format() {
+	case PgFormatPlain:
+		dumpPath += ".sql"
+	case PgFormatCustom:
+		dumpPath += ".dump"
+	}
+
+	if err := runPgDump(pb.Config, pb.dumpArgs(), dumpPath, pb.Progress); err != nil {
+		os.RemoveAll(dumpPath)
+		return nil, fmt.Errorf("pg_dump failed: %w", err)
+	}
+	defer os.RemoveAll(dumpPath)
+
+	var originalSize int64
+	if pb.format() == PgFormatDirectory {
+		// The directory is flat: toc.dat plus one data file per table
+		originalSize, err = compressDirTarGz(dumpPath, outputPath)
+	} else {
+		var dumpInfo os.FileInfo
+		if dumpInfo, err = os.Stat(dumpPath); err != nil {
+			return nil, fmt.Errorf("failed to stat dump file: %w", err)
+		}
+		originalSize = dumpInfo.Size()
+		err = compressTarGz(dumpPath, outputPath)
+	}
+	if err != nil {
+		os.Remove(outputPath)
 		return nil, fmt.Errorf("compression failed: %w", err)
 	}
 
-	// Get compressed size
-	compressedInfo, err := os.Stat(tarGzFilePath)
+	fileInfo, err := os.Stat(outputPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to stat compressed file: %w", err)
 	}
-	compressedSize := compressedInfo.Size()
 
-	// Remove original dump file
-	os.Remove(dumpFilePath)
+	result := &Result{
+		Type:         TypePostgreSQL,
+		Name:         pb.Name,
+		Filename:     filepath.Base(outputPath),
+		Path:         outputPath,
+		Size:         fileInfo.Size(),
+		OriginalSize: originalSize,
+		Duration:     time.Since(startTime),
+		DatabaseName: pb.Config.Database,
+		Timestamp:    startTime,
+		Compression:  CompressionGzip,
+	}
+	result.CompressionPct = result.CalculateCompressionPct()
+
+	return result, nil
+}
+
+// format returns the pg_dump archive format
+func (pb *PostgresBackup) format() string {
+	if pb.Format == "" {
+		return PgFormatPlain
+	}
+	return pb.Format
+}
+
+// dumpArgs builds the pg_dump arguments selecting the archive format and the
+// objects to dump. Archives are written uncompressed, since the tar.gz around
+// them compresses the data anyway.
+func (pb *PostgresBackup) dumpArgs() []string {
+	var args []string
+	switch pb.format() {
+	case PgFormatCustom:
+		args = []string{"--format=custom", "--compress=0"}
+	case PgFormatDirectory:
+		args = []string{"--format=directory", "--compress=0"}
+		if pb.Jobs > 1 {
+			args = append(args, "--jobs", strconv.Itoa(pb.Jobs))
+		}
+	default:
+		args = []string{"--format=plain"}
+	}
+
+	for _, schema := range pb.Schemas {
+		args = append(args, "--schema="+schema)
+	}
+	for _, table := range pb.Tables {
+		args = append(args, "--table="+table)
+	}
+	for _, table := range pb.ExcludeTables {
+		args = append(args, "--exclude-table="+table)
+	}
+	return args
+}
 
-	duration := time.Since(startTime)
-	compressionRatio := float64(compressedSize) / float64(originalSize) * 100
+// DumpPostgres creates a PostgreSQL dump and compresses it to .tar.gz
+//
+// Deprecated: use PostgresBackup.
+func DumpPostgres(config PostgresConfig, backupName string, outputDir string) (*BackupResult, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
 
-	fmt.Printf("✅ Backup completed successfully!\n")
-	fmt.Printf("   Original size: %.2f MB\n", float64(originalSize)/1024/1024)
-	fmt.Printf("   Compressed size: %.2f MB (%.1f%% of original)\n",
-		float64(compressedSize)/1024/1024, compressionRatio)
-	fmt.Printf("   Duration: %v\n", duration.Round(time.Millisecond))
-	fmt.Printf("   Output: %s\n", tarGzFilePath)
+	timestamp := time.Now().Format("20060102-150405")
+	outputPath := filepath.Join(outputDir, fmt.Sprintf("%s-%s.tar.gz", backupName, timestamp))
+
+	pgBackup := &PostgresBackup{Name: backupName, Config: config}
+	result, err := pgBackup.Backup(outputPath)
+	if err != nil {
+		return nil, err
+	}
 
 	return &BackupResult{
-		FilePath:       tarGzFilePath,
-		OriginalSize:   originalSize,
-		CompressedSize: compressedSize,
-		Duration:       duration,
+		FilePath:       result.Path,
+		OriginalSize:   result.OriginalSize,
+		CompressedSize: result.Size,
+		Duration:       result.Duration,
 	}, nil
 }
 
-// runPgDump executes pg_dump command
-func runPgDump(config PostgresConfig, outputPath string) error {
-	// Set PGPASSWORD environment variable
-	env := os.Environ()
+// parsePgDumpVersion converts `pg_dump --version` output to the
+// server_version_num format, e.g. 160000 for 16.x or 90600 for 9.6
+func parsePgDumpVersion(output string) (int, error) {
+	match := pgVersionPattern.FindStringSubmatch(output)
+	if match == nil {
+		return 0, fmt.Errorf("unexpected pg_dump version: %s", strings.TrimSpace(output))
+	}
+
+	major, _ := strconv.Atoi(match[1])
+	if major >= 10 {
+		return major * 10000, nil
+	}
+	minor, _ := strconv.Atoi(match[2])
+	return major*10000 + minor*100, nil
+}
+
+// formatPgVersion formats a server_version_num as a major version
+func formatPgVersion(num int) string {
+	if num >= 100000 {
+		return strconv.Itoa(num / 10000)
+	}
+	return fmt.Sprintf("%d.%d", num/10000, num/100%100)
+}
+
+// pgEnv returns the environment for PostgreSQL client tools
+// The password is passed in the environment, not in argv.
+func pgEnv(config PostgresConfig) []string {
+	env := append(os.Environ(), "PGCONNECT_TIMEOUT=10")
 	if config.Password != "" {
 		env = append(env, fmt.Sprintf("PGPASSWORD=%s", config.Password))
 	}
+	return env
+}
 
-	// Build pg_dump command
+// runPsqlQuery runs a single query and returns its unaligned, trimmed output
+func runPsqlQuery(config PostgresConfig, query string) (string, error) {
 	args := []string{
 		"-h", config.Host,
 		"-p", fmt.Sprintf("%d", config.Port),
 		"-U", config.User,
 		"-d", config.Database,
-		"-f", outputPath,
-		"--verbose",
-		"--format=plain",
+		"--no-psqlrc", "--no-align", "--tuples-only",
+		"-c", query,
+	}
+
+	cmd := exec.Command("psql", args...)
+	cmd.Env = pgEnv(config)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
 	}
 
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// runPgDump executes pg_dump command
+// Verbose progress goes to progress if set; otherwise only errors are kept.
+func runPgDump(config PostgresConfig, args []string, outputPath string, progress io.Writer) error {
+	args = append([]string{
+		"-h", config.Host,
+		"-p", fmt.Sprintf("%d", config.Port),
+		"-U", config.User,
+		"-d", config.Database,
+		"-f", outputPath,
+	}, args...)
+
+	var stderr bytes.Buffer
 	cmd := exec.Command("pg_dump", args...)
-	cmd.Env = env
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Env = pgEnv(config)
+	if progress != nil {
+		cmd.Args = append(cmd.Args, "--verbose")
+		cmd.Stderr = progress
+	} else {
+		cmd.Stderr = &stderr
+	}
 
-	return cmd.Run()
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
 }
 
 // compressTarGz compresses a file to .tar.gz format
@@ -169,10 +417,38 @@ func compressTarGz(inputPath, outputPath string) error {
 	return nil
 }
 
+// PostgresRestoreOptions controls how a PostgreSQL backup is restored
+type PostgresRestoreOptions struct {
+	TargetDB     string // Database to restore into; config.Database if empty
+	Jobs         int    // Parallel pg_restore workers for custom and directory archives
+	AllDatabases bool   // Restore the globals and every database of a server backup
+
+	// Restore only part of a custom or directory archive. Patterns are globs
+	// matched against "schema.table" if they contain a dot, else the table name.
+	Schemas       []string // Only restore objects in these schemas
+	Tables        []string // Only restore these tables with their data, indexes and constraints
+	ExcludeTables []string // Leave out these tables and everything depending on them
+}
+
 // RestorePostgres restores a PostgreSQL database from a .tar.gz backup
-func RestorePostgres(config PostgresConfig, backupFile string, targetDB string) error {
+// The archive format is detected: plain dumps are replayed with psql, custom
+// and directory archives are restored with pg_restore. Server backups restore
+// config.Database, or everything with opts.AllDatabases. Schema and table
+// filters need a custom or directory archive.
+func RestorePostgres(config PostgresConfig, backupFile string, opts PostgresRestoreOptions) error {
 	fmt.Printf("Starting restore from backup: %s\n", backupFile)
 
+	// Turn a plain dump down before a possibly long extraction
+	if opts.selective() {
+		plain, err := isPlainPgDump(backupFile)
+		if err != nil {
+			return err
+		}
+		if plain {
+			return errPgPlainFilters
+		}
+	}
+
 	// Create temporary directory for extraction
 	tempDir, err := os.MkdirTemp("", "pg-restore-*")
 	if err != nil {
@@ -182,20 +458,49 @@ func RestorePostgres(config PostgresConfig, backupFile string, targetDB string)
 
 	// Step 1: Extract .tar.gz
 	fmt.Printf("Extracting backup file...\n")
-	sqlFile, err := extractTarGz(backupFile, tempDir)
+	dumpFile, err := extractTarGz(backupFile, tempDir)
 	if err != nil {
 		return fmt.Errorf("extraction failed: %w", err)
 	}
-	fmt.Printf("Extracted: %s\n", sqlFile)
+
+	if _, err := os.Stat(filepath.Join(tempDir, pgGlobalsFile)); err == nil {
+		return restorePostgresCluster(config, tempDir, backupFile, opts)
+	}
+	if opts.AllDatabases {
+		return fmt.Errorf("the backup contains a single database; restore it with a database name")
+	}
+	if err := opts.validateFilters(); err != nil {
+		return err
+	}
+
+	format, err := detectPgFormat(tempDir, dumpFile)
+	if err != nil {
+		return err
+	}
+	if format == PgFormatDirectory {
+		dumpFile = tempDir
+	}
+	fmt.Printf("Archive format: %s\n", format)
+	if format == PgFormatPlain && opts.selective() {
+		return errPgPlainFilters
+	}
 
 	// Override target database if specified
-	if targetDB != "" {
-		config.Database = targetDB
+	if opts.TargetDB != "" {
+		config.Database = opts.TargetDB
 	}
 
 	// Step 2: Restore to PostgreSQL
 	fmt.Printf("Restoring to database '%s'...\n", config.Database)
-	if err := runPsqlRestore(config, sqlFile); err != nil {
+	if format == PgFormatPlain {
+		if opts.Jobs > 1 {
+			fmt.Printf("⚠️  Plain dumps are restored with psql; --jobs is ignored\n")
+		}
+		err = runPsqlRestore(config, dumpFile)
+	} else {
+		err = runPgRestore(config, dumpFile, opts)
+	}
+	if err != nil {
 		return fmt.Errorf("restore failed: %w", err)
 	}
 
@@ -206,6 +511,60 @@ func RestorePostgres(config PostgresConfig, backupFile string, targetDB string)
 	return nil
 }
 
+// detectPgFormat tells the archive formats apart after extraction
+// Directory archives contain a toc.dat; custom archives start with PGDMP.
+func detectPgFormat(dir string, dumpFile string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, "toc.dat")); err == nil {
+		return PgFormatDirectory, nil
+	}
+
+	file, err := os.Open(dumpFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to open dump file: %w", err)
+	}
+	defer file.Close()
+
+	header := make([]byte, len(pgCustomMagic))
+	if _, err := io.ReadFull(file, header); err == nil && string(header) == pgCustomMagic {
+		return PgFormatCustom, nil
+	}
+	return PgFormatPlain, nil
+}
+
+// isPlainPgDump reports whether a backup holds a plain dump of a single
+// database, reading only the start of the first file in it
+func isPlainPgDump(backupFile string) (bool, error) {
+	file, err := os.Open(backupFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to open backup file: %w", err)
+	}
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return false, fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	defer gzipReader.Close()
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to read tar header: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		start := make([]byte, len(pgPlainMagic))
+		n, _ := io.ReadFull(tarReader, start)
+		return string(start[:n]) == pgPlainMagic, nil
+	}
+}
+
 // extractTarGz extracts a .tar.gz file and returns the path to the extracted SQL file
 func extractTarGz(tarGzPath, destDir string) (string, error) {
 	// Open the tar.gz file
@@ -265,14 +624,44 @@ func extractTarGz(tarGzPath, destDir string) (string, error) {
 	return extractedFile, nil
 }
 
-// runPsqlRestore executes psql command to restore database
-func runPsqlRestore(config PostgresConfig, sqlFilePath string) error {
-	// Set PGPASSWORD environment variable
-	env := os.Environ()
-	if config.Password != "" {
-		env = append(env, fmt.Sprintf("PGPASSWORD=%s", config.Password))
+// runPgRestore executes pg_restore for custom and directory archives
+// With schema or table filters only the selected entries of the archive's
+// table of contents are restored, see writeRestoreList.
+func runPgRestore(config PostgresConfig, archivePath string, opts PostgresRestoreOptions) error {
+	args := []string{
+		"-h", config.Host,
+		"-p", fmt.Sprintf("%d", config.Port),
+		"-U", config.User,
+		"-d", config.Database,
+	}
+	if opts.Jobs > 1 {
+		args = append(args, "--jobs", strconv.Itoa(opts.Jobs))
 	}
+	if opts.selective() {
+		listFile, err := os.CreateTemp("", "pg-restore-list-*")
+		if err != nil {
+			return fmt.Errorf("failed to create restore list: %w", err)
+		}
+		listFile.Close()
+		defer os.Remove(listFile.Name())
 
+		if err := writeRestoreList(archivePath, opts, listFile.Name()); err != nil {
+			return err
+		}
+		args = append(args, "--use-list="+listFile.Name())
+	}
+	args = append(args, archivePath)
+
+	cmd := exec.Command("pg_restore", args...)
+	cmd.Env = pgEnv(config)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+// runPsqlRestore executes psql command to restore database
+func runPsqlRestore(config PostgresConfig, sqlFilePath string) error {
 	// Build psql command
 	args := []string{
 		"-h", config.Host,
@@ -284,7 +673,7 @@ func runPsqlRestore(config PostgresConfig, sqlFilePath string) error {
 	}
 
 	cmd := exec.Command("psql", args...)
-	cmd.Env = env
+	cmd.Env = pgEnv(config)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
@@ -0,0 +1,316 @@
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Files in a PostgreSQL server backup: the globals plus one dump per database
+// named db-<escaped name>.sql or .dump
+const (
+	pgGlobalsFile   = "globals.sql"
+	pgDumpPrefix    = "db-"
+	pgMaintenanceDB = "postgres"
+)
+
+// cluster reports whether a whole server is backed up rather than one database
+func (pb *PostgresBackup) cluster() bool {
+	return pb.AllDatabases || len(pb.Databases) > 0
+}
+
+// connectConfig returns the settings used for server-wide queries
+func (pb *PostgresBackup) connectConfig() PostgresConfig {
+	config := pb.Config
+	if config.Database == "" {
+		config.Database = pgMaintenanceDB
+	}
+	return config
+}
+
+// backupCluster dumps the globals and every selected database into one
+// tar.gz at outputPath
+func (pb *PostgresBackup) backupCluster(outputPath string, startTime time.Time) (*Result, error) {
+	databases, err := pb.discoverDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	workDir := strings.TrimSuffix(outputPath, ".tar.gz")
+	if err := os.MkdirAll(workDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create dump directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	if err := runPgDumpall(pb.connectConfig(), filepath.Join(workDir, pgGlobalsFile)); err != nil {
+		return nil, fmt.Errorf("pg_dumpall --globals-only failed: %w", err)
+	}
+
+	extension := ".sql"
+	if pb.format() == PgFormatCustom {
+		extension = ".dump"
+	}
+
+	sizes := make(map[string]int64, len(databases))
+	for _, database := range databases {
+		config := pb.Config
+		config.Database = database
+		dumpPath := filepath.Join(workDir, pgDumpPrefix+url.PathEscape(database)+extension)
+
+		if err := runPgDump(config, pb.dumpArgs(), dumpPath, pb.Progress); err != nil {
+			return nil, fmt.Errorf("pg_dump of %s failed: %w", database, err)
+		}
+		dumpInfo, err := os.Stat(dumpPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat dump of %s: %w", database, err)
+		}
+		sizes[database] = dumpInfo.Size()
+		fmt.Printf("   %s: %.2f MB\n", database, float64(dumpInfo.Size())/(1024*1024))
+	}
+
+	originalSize, err := compressDirTarGz(workDir, outputPath)
+	if err != nil {
+		os.Remove(outputPath)
+		return nil, fmt.Errorf("compression failed: %w", err)
+	}
+
+	fileInfo, err := os.Stat(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat compressed file: %w", err)
+	}
+
+	label := "all"
+	if !pb.AllDatabases || len(pb.ExcludeDatabases) > 0 {
+		label = strings.Join(databases, ",")
+	}
+
+	result := &Result{
+		Type:         TypePostgreSQL,
+		Name:         pb.Name,
+		Filename:     filepath.Base(outputPath),
+		Path:         outputPath,
+		Size:         fileInfo.Size(),
+		OriginalSize: originalSize,
+		Duration:     time.Since(startTime),
+		DatabaseName: label,
+		Timestamp:    startTime,
+		Compression:  CompressionGzip,
+		Extra:        map[string]string{MetaDatabases: strconv.Itoa(len(sizes))},
+	}
+	result.CompressionPct = result.CalculateCompressionPct()
+
+	return result, nil
+}
+
+// discoverDatabases lists the databases on the server that match the include
+// and exclude patterns. Templates and databases that refuse connections are
+// never dumped.
+func (pb *PostgresBackup) discoverDatabases() ([]string, error) {
+	reply, err := runPsqlQuery(pb.connectConfig(),
+		"SELECT datname FROM pg_database WHERE datallowconn AND NOT datistemplate ORDER BY datname")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list databases: %w", err)
+	}
+
+	var databases []string
+	for _, database := range strings.Split(reply, "\n") {
+		if database == "" {
+			continue
+		}
+		if len(pb.Databases) > 0 && !matchesGlob(pb.Databases, database) {
+			continue
+		}
+		if matchesGlob(pb.ExcludeDatabases, database) {
+			continue
+		}
+		databases = append(databases, database)
+	}
+
+	if len(databases) == 0 {
+		return nil, fmt.Errorf("no databases on the server match the selection")
+	}
+	return databases, nil
+}
+
+// restorePostgresCluster restores a server backup extracted into dir
+// With opts.AllDatabases the globals and every database are restored, each
+// under its own name; otherwise only config.Database, optionally renamed to
+// opts.TargetDB. Missing databases are created.
+func restorePostgresCluster(config PostgresConfig, dir string, backupFile string, opts PostgresRestoreOptions) error {
+	dumps, err := listClusterDumps(dir)
+	if err != nil {
+		return err
+	}
+
+	var selected []string
+	switch {
+	case opts.AllDatabases && opts.TargetDB != "":
+		return fmt.Errorf("cannot restore all databases under a different name")
+	case opts.AllDatabases:
+		for database := range dumps {
+			selected = append(selected, database)
+		}
+		sort.Strings(selected)
+	case config.Database == "":
+		return fmt.Errorf("the backup contains several databases; choose one or restore all databases")
+	default:
+		if _, ok := dumps[config.Database]; !ok {
+			return fmt.Errorf("database %s is not in the backup", config.Database)
+		}
+		selected = []string{config.Database}
+	}
+
+	if opts.AllDatabases && opts.selective() {
+		return fmt.Errorf("schema and table filters need a single database to restore")
+	}
+	if err := opts.validateFilters(); err != nil {
+		return err
+	}
+
+	admin := config
+	admin.Database = pgMaintenanceDB
+
+	// Roles and tablespaces must exist before objects owned by them
+	if opts.AllDatabases {
+		fmt.Printf("Restoring roles and tablespaces...\n")
+		if err := runPsqlRestore(admin, filepath.Join(dir, pgGlobalsFile)); err != nil {
+			return fmt.Errorf("restore of globals failed: %w", err)
+		}
+	}
+
+	for _, database := range selected {
+		target := database
+		if opts.TargetDB != "" {
+			target = opts.TargetDB
+		}
+
+		dumpFile := dumps[database]
+		format, err := detectPgFormat(dir, dumpFile)
+		if err != nil {
+			return err
+		}
+		if format == PgFormatPlain && opts.selective() {
+			return errPgPlainFilters
+		}
+
+		if err := ensurePgDatabase(admin, target); err != nil {
+			return err
+		}
+
+		fmt.Printf("Restoring database '%s'...\n", target)
+		dbConfig := config
+		dbConfig.Database = target
+		if format == PgFormatPlain {
+			err = runPsqlRestore(dbConfig, dumpFile)
+		} else {
+			err = runPgRestore(dbConfig, dumpFile, opts)
+		}
+		if err != nil {
+			return fmt.Errorf("restore of %s failed: %w", target, err)
+		}
+	}
+
+	fmt.Printf("✅ Restore completed successfully!\n")
+	for _, database := range selected {
+		if opts.TargetDB != "" {
+			fmt.Printf("   Database: %s (from %s)\n", opts.TargetDB, database)
+		} else {
+			fmt.Printf("   Database: %s\n", database)
+		}
+	}
+	fmt.Printf("   From: %s\n", backupFile)
+
+	return nil
+}
+
+// listClusterDumps maps database names to their dump files in dir
+func listClusterDumps(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read extracted backup: %w", err)
+	}
+
+	dumps := make(map[string]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, pgDumpPrefix) {
+			continue
+		}
+		escaped := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, pgDumpPrefix), ".sql"), ".dump")
+		database, err := url.PathUnescape(escaped)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected dump file %s in backup", name)
+		}
+		dumps[database] = filepath.Join(dir, name)
+	}
+
+	if len(dumps) == 0 {
+		return nil, fmt.Errorf("no database dumps found in backup")
+	}
+	return dumps, nil
+}
+
+// ensurePgDatabase creates a database unless it already exists
+func ensurePgDatabase(admin PostgresConfig, name string) error {
+	exists, err := runPsqlQuery(admin, "SELECT 1 FROM pg_database WHERE datname = "+quoteSQLString(name))
+	if err != nil {
+		return fmt.Errorf("failed to look up database %s: %w", name, err)
+	}
+	if exists == "1" {
+		return nil
+	}
+
+	fmt.Printf("Creating database '%s'...\n", name)
+	if _, err := runPsqlQuery(admin, "CREATE DATABASE "+quotePgIdentifier(name)); err != nil {
+		return fmt.Errorf("failed to create database %s: %w", name, err)
+	}
+	return nil
+}
+
+// runPgDumpall writes the roles and tablespaces of the server to outputPath
+func runPgDumpall(config PostgresConfig, outputPath string) error {
+	args := []string{
+		"-h", config.Host,
+		"-p", fmt.Sprintf("%d", config.Port),
+		"-U", config.User,
+		"-l", config.Database,
+		"-f", outputPath,
+		"--globals-only",
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("pg_dumpall", args...)
+	cmd.Env = pgEnv(config)
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// matchesGlob reports whether a name matches one of the glob patterns
+func matchesGlob(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// quotePgIdentifier quotes a database name for use in SQL
+func quotePgIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
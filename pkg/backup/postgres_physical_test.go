@@ -0,0 +1,176 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPgStartPointPattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		lsn      string
+		timeline string
+	}{
+		{
+			name: "postgres 10 and later",
+			output: "pg_basebackup: initiating base backup, waiting for checkpoint to complete\n" +
+				"pg_basebackup: checkpoint completed\n" +
+				"pg_basebackup: write-ahead log start point: 0/2000028 on timeline 1\n" +
+				"pg_basebackup: starting background WAL receiver\n" +
+				"pg_basebackup: write-ahead log end point: 0/2000100\n" +
+				"pg_basebackup: base backup completed\n",
+			lsn:      "0/2000028",
+			timeline: "1",
+		},
+		{
+			name: "postgres 9.6",
+			output: "transaction log start point: 1A/7F000060 on timeline 3\n" +
+				"transaction log end point: 1A/7F000130\n",
+			lsn:      "1A/7F000060",
+			timeline: "3",
+		},
+		{
+			name:     "promoted standby",
+			output:   "pg_basebackup: write-ahead log start point: 3/B1000028 on timeline 12\n",
+			lsn:      "3/B1000028",
+			timeline: "12",
+		},
+		{
+			name:   "end point only",
+			output: "pg_basebackup: write-ahead log end point: 0/2000100\n",
+		},
+		{
+			name:   "error",
+			output: "pg_basebackup: error: could not connect to server: Connection refused\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match := pgStartPointPattern.FindStringSubmatch(tt.output)
+			if tt.lsn == "" {
+				if match != nil {
+					t.Fatalf("matched %q, want no start point", match[0])
+				}
+				return
+			}
+			if match == nil {
+				t.Fatal("no start point found")
+			}
+			if match[1] != tt.lsn || match[2] != tt.timeline {
+				t.Errorf("start point %s on timeline %s, want %s on timeline %s", match[1], match[2], tt.lsn, tt.timeline)
+			}
+		})
+	}
+}
+
+func TestLastLine(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{output: "", want: ""},
+		{output: "pg_basebackup: error: connection refused", want: "pg_basebackup: error: connection refused"},
+		{output: "pg_basebackup: checkpoint completed\npg_basebackup: error: disk full\n", want: "pg_basebackup: error: disk full"},
+		{output: "first\nlast\n\n  \n", want: "last"},
+		{output: "first\r\n  indented last  \r\n", want: "indented last"},
+	}
+
+	for _, tt := range tests {
+		if got := lastLine(tt.output); got != tt.want {
+			t.Errorf("lastLine(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestLayoutBaseBackupCleansUpOnFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	// A logical dump extracts fine but is not a base backup
+	dumpPath := filepath.Join(dir, "shop.sql")
+	if err := os.WriteFile(dumpPath, []byte("--\n-- PostgreSQL database dump\n--\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	backupPath := dumpPath + ".tar.gz"
+	if err := compressTarGz(dumpPath, backupPath); err != nil {
+		t.Fatal(err)
+	}
+
+	dataDir := filepath.Join(dir, "main")
+	for i := 0; i < 2; i++ {
+		err := layoutBaseBackup(backupPath, dataDir)
+		if err == nil || !strings.Contains(err.Error(), "no backup_label") {
+			t.Fatalf("attempt %d: err = %v, want a missing backup_label", i+1, err)
+		}
+		entries, err := os.ReadDir(dataDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Fatalf("attempt %d left %d files in the data directory", i+1, len(entries))
+		}
+	}
+}
+
+// fakePgTools puts pg_basebackup 16 and a psql that only accepts replication
+// connections, like a role with just the REPLICATION attribute, first in PATH.
+// The psql answers SHOW server_version_num with serverVersion, or fails like
+// a server before 10 if it is empty.
+func fakePgTools(t *testing.T, serverVersion string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	scripts := map[string]string{
+		"pg_basebackup": "#!/bin/sh\necho 'pg_basebackup (PostgreSQL) 16.2'\n",
+		"psql": `#!/bin/sh
+case "$*" in
+*"-d replication=true "*IDENTIFY_SYSTEM*) echo '7312458915246157312|1|0/3000148|' ;;
+*"-d replication=true "*"SHOW server_version_num"*)
+	[ -n "$SERVER_VERSION" ] || { echo 'ERROR: syntax error' >&2; exit 1; }
+	echo "$SERVER_VERSION" ;;
+*) echo 'FATAL: permission denied for database "postgres"' >&2; exit 2 ;;
+esac
+`,
+	}
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("SERVER_VERSION", serverVersion)
+}
+
+func TestPostgresPhysicalValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		serverVersion string
+		wantErr       string
+	}{
+		{name: "same major", serverVersion: "160004"},
+		{name: "older server", serverVersion: "130012"},
+		{name: "before 10", serverVersion: ""},
+		{name: "newer server", serverVersion: "170001", wantErr: "pg_basebackup 16 is older than the server (17)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakePgTools(t, tt.serverVersion)
+
+			pb := &PostgresPhysicalBackup{Config: PostgresConfig{Host: "db", Port: 5432, User: "replicator", Database: "postgres"}}
+			err := pb.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("err = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
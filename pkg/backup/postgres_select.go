@@ -0,0 +1,347 @@
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// pgTocEntryPattern matches an entry in `pg_restore --list` output
+	pgTocEntryPattern = regexp.MustCompile(`^(\d+); \d+ \d+ (.*)$`)
+	// pgTocObjectPattern splits an entry into its description, "schema name"
+	// and owner. pg_restore prints names unquoted, so they may contain spaces;
+	// the owner is taken to be the last field.
+	pgTocObjectPattern = regexp.MustCompile(`^(SEQUENCE OWNED BY|SEQUENCE SET|MATERIALIZED VIEW DATA|MATERIALIZED VIEW|FOREIGN TABLE|TABLE ATTACH|TABLE DATA|SEQUENCE|SCHEMA|TABLE|VIEW) (.+) \S*$`)
+)
+
+// pgTocObjectDescs are the entries selection works on; other entries are kept
+// or dropped along with what they depend on
+var pgTocObjectDescs = map[string]bool{
+	"SEQUENCE OWNED BY": true,
+	"MATERIALIZED VIEW": true,
+	"FOREIGN TABLE":     true,
+	"SEQUENCE":          true,
+	"SCHEMA":            true,
+	"TABLE":             true,
+	"VIEW":              true,
+}
+
+// pgTocEntry is one entry of an archive's table of contents
+type pgTocEntry struct {
+	id     int
+	line   string
+	desc   string // Only set for pgTocObjectDescs
+	schema string
+	name   string
+	deps   []int
+}
+
+// relation reports whether the entry creates a table-like object
+func (e *pgTocEntry) relation() bool {
+	switch e.desc {
+	case "TABLE", "VIEW", "MATERIALIZED VIEW", "FOREIGN TABLE", "SEQUENCE":
+		return true
+	}
+	return false
+}
+
+// selective reports whether only part of the archive is restored
+func (opts PostgresRestoreOptions) selective() bool {
+	return len(opts.Schemas) > 0 || len(opts.Tables) > 0 || len(opts.ExcludeTables) > 0
+}
+
+// validateFilters rejects filter combinations pg_dump would not honour either
+func (opts PostgresRestoreOptions) validateFilters() error {
+	if len(opts.Schemas) > 0 && len(opts.Tables) > 0 {
+		return fmt.Errorf("cannot combine schema and table filters; qualify the tables with their schema instead")
+	}
+	return nil
+}
+
+// writeRestoreList writes the pg_restore --use-list file restoring the parts
+// of an archive selected by opts
+// Tables come with their data and everything that only depends on them and
+// other restored objects: indexes, constraints, defaults, owned sequences,
+// comments and grants. Excluded tables take everything depending on them
+// along, such as foreign keys pointing at them and views over them.
+func writeRestoreList(archivePath string, opts PostgresRestoreOptions, listPath string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("pg_restore", "--list", "--verbose", archivePath)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("pg_restore --list failed: %w: %s", err, msg)
+		}
+		return fmt.Errorf("pg_restore --list failed: %w", err)
+	}
+
+	entries, err := parsePgToc(string(output))
+	if err != nil {
+		return err
+	}
+
+	sel := newPgTocSelection(entries, opts)
+	var list strings.Builder
+	tables := 0
+	for _, entry := range entries {
+		if !sel.keep(entry.id) {
+			continue
+		}
+		if entry.relation() {
+			tables++
+		}
+		list.WriteString(entry.line)
+		list.WriteString("\n")
+	}
+	if tables == 0 {
+		return fmt.Errorf("no tables in the backup match the schema and table filters")
+	}
+
+	if err := os.WriteFile(listPath, []byte(list.String()), 0600); err != nil {
+		return fmt.Errorf("failed to write restore list: %w", err)
+	}
+	fmt.Printf("Selected %d tables, views and sequences to restore\n", tables)
+	return nil
+}
+
+// parsePgToc parses `pg_restore --list --verbose` output, whose comment line
+// after each entry lists the entries it depends on
+func parsePgToc(output string) ([]*pgTocEntry, error) {
+	var entries []*pgTocEntry
+	for _, line := range strings.Split(output, "\n") {
+		if deps, ok := strings.CutPrefix(line, ";\tdepends on:"); ok {
+			if len(entries) == 0 {
+				return nil, fmt.Errorf("unexpected pg_restore --list output: %s", line)
+			}
+			last := entries[len(entries)-1]
+			for _, field := range strings.Fields(deps) {
+				id, err := strconv.Atoi(field)
+				if err != nil {
+					return nil, fmt.Errorf("unexpected pg_restore --list output: %s", line)
+				}
+				last.deps = append(last.deps, id)
+			}
+			continue
+		}
+
+		match := pgTocEntryPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		id, _ := strconv.Atoi(match[1])
+		entry := &pgTocEntry{id: id, line: line}
+		if object := pgTocObjectPattern.FindStringSubmatch(match[2]); object != nil && pgTocObjectDescs[object[1]] {
+			// Split later, once all schema names are known
+			entry.desc, entry.name = object[1], object[2]
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no entries found in the archive's table of contents")
+	}
+
+	// Schemas are listed as "- name"; public is not listed by older servers
+	schemas := map[string]bool{"public": true}
+	for _, entry := range entries {
+		if entry.desc == "SCHEMA" {
+			entry.schema, entry.name, _ = strings.Cut(entry.name, " ")
+			schemas[entry.name] = true
+		}
+	}
+	for _, entry := range entries {
+		if entry.desc != "" && entry.desc != "SCHEMA" {
+			entry.schema, entry.name = splitPgTocName(entry.name, schemas)
+		}
+	}
+	return entries, nil
+}
+
+// splitPgTocName splits "schema name" at the longest known schema, as either
+// part may contain spaces
+func splitPgTocName(qualified string, schemas map[string]bool) (string, string) {
+	schema := ""
+	for candidate := range schemas {
+		if len(candidate) > len(schema) && strings.HasPrefix(qualified, candidate+" ") {
+			schema = candidate
+		}
+	}
+	if schema == "" {
+		schema, name, _ := strings.Cut(qualified, " ")
+		return schema, name
+	}
+	return schema, qualified[len(schema)+1:]
+}
+
+// pgTocSelection decides which entries of a table of contents are restored
+type pgTocSelection struct {
+	opts     PostgresRestoreOptions
+	entries  map[int]*pgTocEntry
+	owners   map[int]*pgTocEntry // Owning table of sequences for serial and identity columns
+	schemas  map[string]bool     // Schemas of the tables selected with opts.Tables
+	excluded map[int]bool
+	kept     map[int]bool
+}
+
+func newPgTocSelection(entries []*pgTocEntry, opts PostgresRestoreOptions) *pgTocSelection {
+	sel := &pgTocSelection{
+		opts:     opts,
+		entries:  make(map[int]*pgTocEntry, len(entries)),
+		owners:   make(map[int]*pgTocEntry),
+		schemas:  make(map[string]bool),
+		excluded: make(map[int]bool),
+		kept:     make(map[int]bool),
+	}
+	for _, entry := range entries {
+		sel.entries[entry.id] = entry
+	}
+
+	// Identity sequences depend on their table; SEQUENCE OWNED BY, written
+	// for serial columns, depends on both the sequence and the table
+	for _, entry := range entries {
+		if entry.desc != "SEQUENCE" && entry.desc != "SEQUENCE OWNED BY" {
+			continue
+		}
+		sequence, table := entry, (*pgTocEntry)(nil)
+		for _, dep := range entry.deps {
+			switch target := sel.entries[dep]; {
+			case target == nil:
+			case target.desc == "SEQUENCE":
+				sequence = target
+			case target.desc == "TABLE":
+				table = target
+			}
+		}
+		if sequence.desc == "SEQUENCE" && table != nil {
+			sel.owners[sequence.id] = table
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.relation() && len(opts.Tables) > 0 && sel.selected(entry) {
+			sel.schemas[entry.schema] = true
+		}
+	}
+	return sel
+}
+
+// matches reports whether a relation, or the table owning a sequence,
+// matches one of the patterns
+func (sel *pgTocSelection) matches(patterns []string, entry *pgTocEntry) bool {
+	if matchesTablePattern(patterns, entry.schema, entry.name) {
+		return true
+	}
+	owner := sel.owners[entry.id]
+	return owner != nil && matchesTablePattern(patterns, owner.schema, owner.name)
+}
+
+// selected reports whether a relation is picked by the include filters
+func (sel *pgTocSelection) selected(entry *pgTocEntry) bool {
+	switch {
+	case len(sel.opts.Tables) > 0:
+		return sel.matches(sel.opts.Tables, entry)
+	case len(sel.opts.Schemas) > 0:
+		return matchesGlob(sel.opts.Schemas, entry.schema)
+	default:
+		return true
+	}
+}
+
+// dropped reports whether an entry is or depends on an excluded table
+func (sel *pgTocSelection) dropped(id int) bool {
+	if result, ok := sel.excluded[id]; ok {
+		return result
+	}
+	entry := sel.entries[id]
+	if entry == nil {
+		return false
+	}
+
+	// Dependencies form no cycles, but never loop on a malformed listing
+	sel.excluded[id] = false
+	result := entry.relation() && sel.matches(sel.opts.ExcludeTables, entry)
+	for _, dep := range entry.deps {
+		if result {
+			break
+		}
+		result = sel.dropped(dep)
+	}
+	sel.excluded[id] = result
+	return result
+}
+
+// keep reports whether an entry is restored
+func (sel *pgTocSelection) keep(id int) bool {
+	if result, ok := sel.kept[id]; ok {
+		return result
+	}
+	entry := sel.entries[id]
+	if entry == nil {
+		// Not in the archive, so it has to exist in the target already
+		return true
+	}
+
+	sel.kept[id] = false
+	result := sel.decide(entry)
+	sel.kept[id] = result
+	return result
+}
+
+func (sel *pgTocSelection) decide(entry *pgTocEntry) bool {
+	if sel.dropped(entry.id) {
+		return false
+	}
+	if entry.relation() {
+		return sel.selected(entry)
+	}
+
+	filtered := len(sel.opts.Tables) > 0 || len(sel.opts.Schemas) > 0
+	if !filtered {
+		return true
+	}
+	if entry.desc == "SCHEMA" {
+		if len(sel.opts.Schemas) > 0 {
+			return matchesGlob(sel.opts.Schemas, entry.name)
+		}
+		// public exists in every database
+		return entry.name != "public" && sel.schemas[entry.name]
+	}
+
+	// Anything else follows what it depends on; with tables selected it must
+	// belong to one of them rather than just live in the same schema
+	if len(entry.deps) == 0 {
+		return false
+	}
+	belongs := len(sel.opts.Tables) == 0
+	for _, dep := range entry.deps {
+		if !sel.keep(dep) {
+			return false
+		}
+		if target := sel.entries[dep]; target != nil && target.desc != "SCHEMA" {
+			belongs = true
+		}
+	}
+	return belongs
+}
+
+// matchesTablePattern reports whether a table matches one of the glob
+// patterns, which are matched against "schema.table" if they contain a dot
+func matchesTablePattern(patterns []string, schema string, name string) bool {
+	for _, pattern := range patterns {
+		target := name
+		if strings.Contains(pattern, ".") {
+			target = schema + "." + name
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
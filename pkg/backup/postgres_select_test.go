@@ -0,0 +1,192 @@
+package backup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// readPgToc parses testdata/pg_restore_list.txt, `pg_restore --list --verbose`
+// output of a shop database with a sales schema and a schema and a table whose
+// names contain spaces
+func readPgToc(t *testing.T) []*pgTocEntry {
+	t.Helper()
+
+	output, err := os.ReadFile(filepath.Join("testdata", "pg_restore_list.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := parsePgToc(string(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestParsePgToc(t *testing.T) {
+	entries := readPgToc(t)
+	if len(entries) != 29 {
+		t.Fatalf("parsed %d entries, want 29", len(entries))
+	}
+
+	byID := make(map[int]*pgTocEntry)
+	for _, entry := range entries {
+		byID[entry.id] = entry
+	}
+
+	tests := []struct {
+		id     int
+		desc   string
+		schema string
+		name   string
+		deps   []int
+	}{
+		{id: 5, desc: "SCHEMA", schema: "-", name: "public"},
+		{id: 7, desc: "SCHEMA", schema: "-", name: "big data"},
+		{id: 217, desc: "TABLE", schema: "public", name: "customers", deps: []int{5}},
+		{id: 221, desc: "TABLE", schema: "public", name: "order items", deps: []int{5}},
+		{id: 224, desc: "TABLE", schema: "big data", name: "events", deps: []int{7}},
+		{id: 223, desc: "VIEW", schema: "public", name: "customer_orders", deps: []int{217, 219, 5}},
+		{id: 3481, desc: "SEQUENCE OWNED BY", schema: "public", name: "customers_id_seq", deps: []int{218, 217}},
+		// Entries that only start like an object are not mistaken for one
+		{id: 3472, deps: []int{221}},
+		{id: 3490, deps: []int{218}},
+		{id: 3331, deps: []int{219, 221, 3311}},
+	}
+
+	for _, tt := range tests {
+		entry := byID[tt.id]
+		if entry == nil {
+			t.Errorf("entry %d not parsed", tt.id)
+			continue
+		}
+		if entry.desc != tt.desc || entry.schema != tt.schema || entry.name != tt.name {
+			t.Errorf("entry %d = %q %q %q, want %q %q %q", tt.id, entry.desc, entry.schema, entry.name, tt.desc, tt.schema, tt.name)
+		}
+		if !slices.Equal(entry.deps, tt.deps) {
+			t.Errorf("entry %d depends on %v, want %v", tt.id, entry.deps, tt.deps)
+		}
+	}
+}
+
+func TestParsePgTocRejectsEmptyListing(t *testing.T) {
+	if _, err := parsePgToc(";\n; Selected TOC Entries:\n;\n"); err == nil {
+		t.Error("empty listing accepted")
+	}
+	if _, err := parsePgToc(";\tdepends on: 5\n"); err == nil {
+		t.Error("dependency without an entry accepted")
+	}
+}
+
+func TestPgTocSelection(t *testing.T) {
+	tests := []struct {
+		name string
+		opts PostgresRestoreOptions
+		want []int
+	}{
+		{
+			name: "schema",
+			opts: PostgresRestoreOptions{Schemas: []string{"sales"}},
+			want: []int{6, 222, 3473, 3500},
+		},
+		{
+			name: "schema with a space",
+			opts: PostgresRestoreOptions{Schemas: []string{"big data"}},
+			want: []int{7, 224, 3474},
+		},
+		{
+			name: "table with identity sequence and index",
+			opts: PostgresRestoreOptions{Tables: []string{"orders"}},
+			want: []int{219, 220, 3471, 3491, 3311, 3320},
+		},
+		{
+			name: "table with serial sequence, default and grant",
+			opts: PostgresRestoreOptions{Tables: []string{"public.customers"}},
+			want: []int{217, 218, 3481, 3300, 3470, 3490, 3310, 3501},
+		},
+		{
+			name: "table outside public brings its schema",
+			opts: PostgresRestoreOptions{Tables: []string{"sales.invoices"}},
+			want: []int{6, 222, 3473},
+		},
+		{
+			name: "table with a space",
+			opts: PostgresRestoreOptions{Tables: []string{"order items"}},
+			want: []int{221, 3472, 3321},
+		},
+		{
+			name: "glob keeps foreign keys between selected tables",
+			opts: PostgresRestoreOptions{Tables: []string{"public.order*"}},
+			want: []int{219, 220, 221, 3471, 3472, 3491, 3311, 3320, 3321, 3331},
+		},
+		{
+			name: "exclude table drops what depends on it",
+			opts: PostgresRestoreOptions{ExcludeTables: []string{"customers"}},
+			want: []int{5, 3480, 6, 7, 219, 220, 221, 222, 224, 3471, 3472, 3473, 3474, 3491, 3311, 3320, 3321, 3331, 3500},
+		},
+		{
+			name: "schema without an excluded table",
+			opts: PostgresRestoreOptions{Schemas: []string{"public"}, ExcludeTables: []string{"public.order items"}},
+			want: []int{5, 3480, 217, 218, 3481, 219, 220, 223, 3300, 3470, 3471, 3490, 3491, 3310, 3311, 3320, 3330, 3501},
+		},
+	}
+
+	entries := readPgToc(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sel := newPgTocSelection(entries, tt.opts)
+			var got []int
+			for _, entry := range entries {
+				if sel.keep(entry.id) {
+					got = append(got, entry.id)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("restored %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestorePostgresRejectsFiltersOnPlainDump(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name  string
+		dump  string
+		plain bool
+	}{
+		{name: "shop.sql", dump: "--\n-- PostgreSQL database dump\n--\n\nSET statement_timeout = 0;\n", plain: true},
+		{name: "shop.dump", dump: pgCustomMagic + "\x01\x0f\x00"},
+		{name: "globals.sql", dump: "--\n-- PostgreSQL database cluster dump\n--\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dumpPath := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(dumpPath, []byte(tt.dump), 0600); err != nil {
+				t.Fatal(err)
+			}
+			backupPath := dumpPath + ".tar.gz"
+			if err := compressTarGz(dumpPath, backupPath); err != nil {
+				t.Fatal(err)
+			}
+
+			plain, err := isPlainPgDump(backupPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if plain != tt.plain {
+				t.Errorf("isPlainPgDump = %v, want %v", plain, tt.plain)
+			}
+		})
+	}
+
+	err := RestorePostgres(PostgresConfig{Database: "shop"}, filepath.Join(dir, "shop.sql.tar.gz"),
+		PostgresRestoreOptions{Tables: []string{"orders"}})
+	if !errors.Is(err, errPgPlainFilters) {
+		t.Errorf("err = %v, want %v", err, errPgPlainFilters)
+	}
+}
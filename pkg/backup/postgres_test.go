@@ -0,0 +1,89 @@
+package backup
+
+import "testing"
+
+func TestParsePgDumpVersion(t *testing.T) {
+	tests := []struct {
+		output  string
+		want    int
+		wantErr bool
+	}{
+		{output: "pg_dump (PostgreSQL) 16.2\n", want: 160000},
+		{output: "pg_dump (PostgreSQL) 9.6.24\n", want: 90600},
+		{output: "pg_dump (PostgreSQL) 10.23\n", want: 100000},
+		{output: "pg_dump (PostgreSQL) 16devel\n", want: 160000},
+		{output: "pg_dump (PostgreSQL) 17beta1\n", want: 170000},
+		{output: "pg_dump (PostgreSQL) 15.5 (Debian 15.5-1.pgdg120+1)\n", want: 150000},
+		{output: "pg_dump (PostgreSQL) 14.10 (Ubuntu 14.10-0ubuntu0.22.04.1)\n", want: 140000},
+		{output: "pg_dump: command not found\n", wantErr: true},
+		{output: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			got, err := parsePgDumpVersion(tt.output)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parsePgDumpVersion(%q) = %d, want an error", tt.output, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("parsePgDumpVersion(%q) = %d, want %d", tt.output, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatPgVersion(t *testing.T) {
+	tests := []struct {
+		num  int
+		want string
+	}{
+		{num: 160002, want: "16"},
+		{num: 160000, want: "16"},
+		{num: 100023, want: "10"},
+		{num: 90624, want: "9.6"},
+		{num: 90600, want: "9.6"},
+		{num: 80423, want: "8.4"},
+	}
+
+	for _, tt := range tests {
+		if got := formatPgVersion(tt.num); got != tt.want {
+			t.Errorf("formatPgVersion(%d) = %q, want %q", tt.num, got, tt.want)
+		}
+	}
+}
+
+func TestCheckPgDumpVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		client  string // pg_dump --version output
+		server  int    // server_version_num
+		wantErr bool
+	}{
+		{name: "same major, older minor", client: "pg_dump (PostgreSQL) 16.2", server: 160004},
+		{name: "newer client", client: "pg_dump (PostgreSQL) 17.0", server: 90624},
+		{name: "development client", client: "pg_dump (PostgreSQL) 16devel", server: 160002},
+		{name: "same 9.x major", client: "pg_dump (PostgreSQL) 9.6.24", server: 90624},
+		{name: "older client", client: "pg_dump (PostgreSQL) 15.5", server: 160002, wantErr: true},
+		{name: "older 9.x minor", client: "pg_dump (PostgreSQL) 9.5.25", server: 90624, wantErr: true},
+		{name: "9.x client for 10", client: "pg_dump (PostgreSQL) 9.6.24", server: 100023, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := parsePgDumpVersion(tt.client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = checkPgDumpVersion(client, tt.server)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkPgDumpVersion(%d, %d) = %v, want error %v", client, tt.server, err, tt.wantErr)
+			}
+		})
+	}
+}
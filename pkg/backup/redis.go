@@ -0,0 +1,472 @@
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Redis snapshot modes
+const (
+	// RedisModeReplica pulls an RDB over the replication protocol, which works
+	// against remote instances
+	RedisModeReplica = "replica"
+	// RedisModeBGSave triggers BGSAVE and copies the RDB file the server
+	// writes, which requires access to the server's data directory
+	RedisModeBGSave = "bgsave"
+)
+
+// redisMagic is the header every RDB file starts with
+const redisMagic = "REDIS"
+
+// RedisConfig holds the connection settings for a Redis instance
+type RedisConfig struct {
+	Host     string
+	Port     int
+	Username string // ACL user, empty for the default user
+	Password string
+}
+
+// RedisBackup snapshots a Redis instance into a compressed RDB file
+type RedisBackup struct {
+	Name        string
+	Config      RedisConfig
+	Mode        string        // RedisModeReplica (default) or RedisModeBGSave
+	RDBPath     string        // RDB file written by BGSAVE; asked from the server if empty
+	SaveTimeout time.Duration // How long to wait for BGSAVE to finish
+}
+
+// Validate checks if the configuration is valid
+func (rb *RedisBackup) Validate() error {
+	if _, err := exec.LookPath("redis-cli"); err != nil {
+		return fmt.Errorf("redis-cli not found in PATH: %w", err)
+	}
+
+	switch rb.mode() {
+	case RedisModeReplica, RedisModeBGSave:
+	default:
+		return fmt.Errorf("unsupported redis mode: %s (supported: %s, %s)", rb.Mode, RedisModeReplica, RedisModeBGSave)
+	}
+
+	// redis-cli exits successfully on server errors such as NOAUTH
+	output, err := runRedisCLI(rb.Config, "PING")
+	if err != nil {
+		return fmt.Errorf("cannot reach redis at %s:%d: %w", rb.Config.Host, rb.Config.Port, err)
+	}
+	if output != "PONG" {
+		return fmt.Errorf("redis at %s:%d: %s", rb.Config.Host, rb.Config.Port, output)
+	}
+
+	return nil
+}
+
+// Backup takes an RDB snapshot and packages it as a tar.gz archive
+func (rb *RedisBackup) Backup(outputPath string) (*Result, error) {
+	startTime := time.Now()
+
+	if err := rb.Validate(); err != nil {
+		return nil, err
+	}
+
+	rdbPath := strings.TrimSuffix(outputPath, ".tar.gz") + ".rdb"
+
+	var err error
+	if rb.mode() == RedisModeBGSave {
+		err = rb.snapshotBGSave(rdbPath)
+	} else {
+		err = rb.snapshotReplica(rdbPath)
+	}
+	if err != nil {
+		os.Remove(rdbPath)
+		return nil, err
+	}
+	defer os.Remove(rdbPath)
+
+	if err := checkRDB(rdbPath); err != nil {
+		return nil, err
+	}
+
+	rdbInfo, err := os.Stat(rdbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat snapshot: %w", err)
+	}
+
+	if err := compressTarGz(rdbPath, outputPath); err != nil {
+		os.Remove(outputPath)
+		return nil, fmt.Errorf("compression failed: %w", err)
+	}
+
+	fileInfo, err := os.Stat(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat compressed file: %w", err)
+	}
+
+	result := &Result{
+		Type:         TypeRedis,
+		Name:         rb.Name,
+		Filename:     filepath.Base(outputPath),
+		Path:         outputPath,
+		Size:         fileInfo.Size(),
+		OriginalSize: rdbInfo.Size(),
+		Duration:     time.Since(startTime),
+		DatabaseName: fmt.Sprintf("%s:%d", rb.Config.Host, rb.Config.Port),
+		Timestamp:    startTime,
+		Compression:  CompressionGzip,
+	}
+	result.CompressionPct = result.CalculateCompressionPct()
+
+	return result, nil
+}
+
+// mode returns the snapshot mode, defaulting to replica
+func (rb *RedisBackup) mode() string {
+	if rb.Mode == "" {
+		return RedisModeReplica
+	}
+	return rb.Mode
+}
+
+// snapshotReplica pulls an RDB from the server with `redis-cli --rdb`
+func (rb *RedisBackup) snapshotReplica(rdbPath string) error {
+	fmt.Printf("Pulling RDB snapshot from %s:%d...\n", rb.Config.Host, rb.Config.Port)
+	if _, err := runRedisCLI(rb.Config, "--rdb", rdbPath); err != nil {
+		return fmt.Errorf("redis-cli --rdb failed: %w", err)
+	}
+	return nil
+}
+
+// snapshotBGSave triggers BGSAVE, waits for it to complete and copies the
+// resulting RDB file
+func (rb *RedisBackup) snapshotBGSave(rdbPath string) error {
+	before, err := redisLastSave(rb.Config)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Triggering BGSAVE on %s:%d...\n", rb.Config.Host, rb.Config.Port)
+	output, err := runRedisCLI(rb.Config, "BGSAVE")
+	if err != nil {
+		return fmt.Errorf("BGSAVE failed: %w", err)
+	}
+	// A save that is already running will also produce a fresh snapshot
+	if strings.HasPrefix(output, "ERR") && !strings.Contains(output, "in progress") {
+		return fmt.Errorf("BGSAVE failed: %s", output)
+	}
+
+	timeout := rb.SaveTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Minute
+	}
+	deadline := time.Now().Add(timeout)
+
+	for {
+		last, err := redisLastSave(rb.Config)
+		if err != nil {
+			return err
+		}
+		if last > before {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("BGSAVE did not complete within %s", timeout)
+		}
+		time.Sleep(time.Second)
+	}
+
+	info, err := runRedisCLI(rb.Config, "INFO", "persistence")
+	if err != nil {
+		return fmt.Errorf("failed to read persistence info: %w", err)
+	}
+	if !strings.Contains(info, "rdb_last_bgsave_status:ok") {
+		return fmt.Errorf("BGSAVE reported a failure, see the redis server log")
+	}
+
+	source := rb.RDBPath
+	if source == "" {
+		if source, err = redisRDBPath(rb.Config); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Copying snapshot %s...\n", source)
+	return copyFile(source, rdbPath)
+}
+
+// redisLastSave returns the UNIX time of the last successful save
+func redisLastSave(config RedisConfig) (int64, error) {
+	output, err := runRedisCLI(config, "LASTSAVE")
+	if err != nil {
+		return 0, fmt.Errorf("LASTSAVE failed: %w", err)
+	}
+
+	last, err := strconv.ParseInt(output, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected LASTSAVE reply: %s", output)
+	}
+	return last, nil
+}
+
+// redisRDBPath asks the server where it writes its RDB file
+func redisRDBPath(config RedisConfig) (string, error) {
+	dir, err := redisConfigGet(config, "dir")
+	if err != nil {
+		return "", err
+	}
+	filename, err := redisConfigGet(config, "dbfilename")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filename), nil
+}
+
+// redisConfigGet reads a single server configuration value
+func redisConfigGet(config RedisConfig, name string) (string, error) {
+	output, err := runRedisCLI(config, "CONFIG", "GET", name)
+	if err != nil {
+		return "", fmt.Errorf("CONFIG GET %s failed (set the RDB path explicitly if CONFIG is disabled): %w", name, err)
+	}
+
+	// The reply is the name followed by the value on the next line
+	lines := strings.Split(output, "\n")
+	if len(lines) < 2 {
+		return "", fmt.Errorf("unexpected CONFIG GET %s reply: %s", name, output)
+	}
+	return strings.TrimSpace(lines[1]), nil
+}
+
+// runRedisCLI runs redis-cli against the configured instance and returns its
+// trimmed output. The password is passed in the environment, not in argv.
+func runRedisCLI(config RedisConfig, args ...string) (string, error) {
+	base := []string{"-h", config.Host, "-p", strconv.Itoa(config.Port)}
+	if config.Username != "" {
+		base = append(base, "--user", config.Username)
+	}
+
+	cmd := exec.Command("redis-cli", append(base, args...)...)
+	cmd.Env = os.Environ()
+	if config.Password != "" {
+		cmd.Env = append(cmd.Env, "REDISCLI_AUTH="+config.Password)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// checkRDB verifies that a file looks like an RDB snapshot
+func checkRDB(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer file.Close()
+
+	header := make([]byte, len(redisMagic))
+	if _, err := io.ReadFull(file, header); err != nil || string(header) != redisMagic {
+		return fmt.Errorf("%s is not a valid RDB snapshot", filepath.Base(path))
+	}
+	return nil
+}
+
+// copyFile copies src to dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	return out.Close()
+}
+
+// RedisRestoreOptions controls where a Redis snapshot is installed
+type RedisRestoreOptions struct {
+	DataDir     string       // Data directory of the target instance, asked from a running instance if empty
+	RDBFilename string       // dbfilename of the target instance, default dump.rdb
+	Instance    *RedisConfig // Instance that loads the snapshot
+
+	// RestartCommand restarts the instance so it loads the installed
+	// snapshot, e.g. "systemctl restart redis". Without it the instance must
+	// be stopped while the file is replaced.
+	RestartCommand string
+	StartTimeout   time.Duration // How long to wait for the restarted instance to load the snapshot
+}
+
+// RestoreRedis installs the RDB from a backup into a Redis data directory.
+// Redis only loads an RDB at startup, so either the target instance is
+// stopped while the file is replaced and started afterwards, or a running
+// instance is restarted with opts.RestartCommand. The existing file is
+// replaced atomically and kept as <file>.bak.
+func RestoreRedis(backupFile string, opts RedisRestoreOptions) (err error) {
+	fmt.Printf("Starting restore from backup: %s\n", backupFile)
+
+	if opts.RestartCommand != "" && opts.Instance == nil {
+		return fmt.Errorf("the redis instance is required to restart it")
+	}
+
+	running := false
+	if opts.Instance != nil {
+		_, pingErr := runRedisCLI(*opts.Instance, "PING")
+		running = pingErr == nil
+	}
+	if running {
+		if opts.RestartCommand == "" {
+			// A running server would overwrite the restored file when it next saves
+			return fmt.Errorf("redis at %s:%d is still running; stop it before restoring or pass a restart command", opts.Instance.Host, opts.Instance.Port)
+		}
+
+		var restoreSave func()
+		restoreSave, err = prepareRedisReload(*opts.Instance, &opts)
+		if err != nil {
+			return err
+		}
+		// Until the restart, put the save points back if the restore fails
+		defer func() {
+			if err != nil {
+				restoreSave()
+			}
+		}()
+	}
+
+	if opts.DataDir == "" {
+		return fmt.Errorf("redis data directory is required")
+	}
+	if opts.RDBFilename == "" {
+		opts.RDBFilename = "dump.rdb"
+	}
+
+	// Extract next to the destination so the final rename is atomic
+	tempDir, err := os.MkdirTemp(opts.DataDir, ".redis-restore-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	fmt.Printf("Extracting backup file...\n")
+	rdbFile, err := extractTarGz(backupFile, tempDir)
+	if err != nil {
+		return fmt.Errorf("extraction failed: %w", err)
+	}
+	if err := checkRDB(rdbFile); err != nil {
+		return err
+	}
+
+	target := filepath.Join(opts.DataDir, opts.RDBFilename)
+	if _, err := os.Stat(target); err == nil {
+		if err := copyFile(target, target+".bak"); err != nil {
+			return fmt.Errorf("failed to keep a copy of the current snapshot: %w", err)
+		}
+		fmt.Printf("Previous snapshot saved as %s.bak\n", target)
+	}
+
+	if err := os.Rename(rdbFile, target); err != nil {
+		return fmt.Errorf("failed to install snapshot: %w", err)
+	}
+
+	if opts.RestartCommand != "" {
+		fmt.Printf("Restarting Redis...\n")
+		cmd := exec.Command("sh", "-c", opts.RestartCommand)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("restart command failed (the snapshot is installed at %s): %w", target, err)
+		}
+		if err := waitForRedisLoad(*opts.Instance, opts.StartTimeout); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("✅ Restore completed successfully!\n")
+	fmt.Printf("   Snapshot: %s\n", target)
+	fmt.Printf("   From: %s\n", backupFile)
+	if opts.RestartCommand == "" {
+		fmt.Printf("   Start Redis to load it (disable appendonly or remove the AOF first, or Redis loads the AOF instead)\n")
+	}
+
+	return nil
+}
+
+// prepareRedisReload readies a running instance for a restart that loads a
+// new snapshot: it fills in the data directory and file name from the server,
+// refuses instances that would load an AOF instead, and disables the save
+// points so stopping the server does not overwrite the installed file. The
+// save points come back from the configuration file on restart; the returned
+// function puts them back if the restore fails before that.
+func prepareRedisReload(config RedisConfig, opts *RedisRestoreOptions) (func(), error) {
+	appendonly, err := redisConfigGet(config, "appendonly")
+	if err != nil {
+		return nil, err
+	}
+	if appendonly == "yes" {
+		return nil, fmt.Errorf("redis at %s:%d has appendonly enabled and would load the AOF instead of the snapshot; disable it in the configuration first", config.Host, config.Port)
+	}
+
+	if opts.DataDir == "" {
+		if opts.DataDir, err = redisConfigGet(config, "dir"); err != nil {
+			return nil, err
+		}
+	}
+	if opts.RDBFilename == "" {
+		if opts.RDBFilename, err = redisConfigGet(config, "dbfilename"); err != nil {
+			return nil, err
+		}
+	}
+
+	save, err := redisConfigGet(config, "save")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := runRedisCLI(config, "CONFIG", "SET", "save", ""); err != nil {
+		return nil, fmt.Errorf("failed to disable save points: %w", err)
+	}
+
+	return func() {
+		if _, err := runRedisCLI(config, "CONFIG", "SET", "save", save); err != nil {
+			fmt.Printf("⚠️  Failed to restore the save points of redis at %s:%d: %v\n", config.Host, config.Port, err)
+		}
+	}, nil
+}
+
+// waitForRedisLoad waits until a restarted instance answers and has finished
+// loading its snapshot
+func waitForRedisLoad(config RedisConfig, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+	deadline := time.Now().Add(timeout)
+
+	for {
+		info, err := runRedisCLI(config, "INFO", "persistence")
+		if err == nil && strings.Contains(info, "loading:0") {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("redis at %s:%d did not load the snapshot within %s", config.Host, config.Port, timeout)
+		}
+		time.Sleep(time.Second)
+	}
+}
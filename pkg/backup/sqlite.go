@@ -0,0 +1,240 @@
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SQLite snapshot methods
+const (
+	// SQLiteMethodBackup copies the database page by page with the online
+	// backup API (the sqlite3 shell's .backup command)
+	SQLiteMethodBackup = "backup"
+	// SQLiteMethodVacuum writes a compacted copy with VACUUM INTO
+	SQLiteMethodVacuum = "vacuum"
+)
+
+// SQLiteBackup takes a consistent snapshot of a live SQLite database
+type SQLiteBackup struct {
+	Name         string
+	DatabasePath string
+	Method       string // SQLiteMethodBackup (default) or SQLiteMethodVacuum
+}
+
+// Validate checks if the configuration is valid
+func (sb *SQLiteBackup) Validate() error {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		return fmt.Errorf("sqlite3 not found in PATH: %w", err)
+	}
+
+	switch sb.method() {
+	case SQLiteMethodBackup, SQLiteMethodVacuum:
+	default:
+		return fmt.Errorf("unsupported sqlite method: %s (supported: %s, %s)", sb.Method, SQLiteMethodBackup, SQLiteMethodVacuum)
+	}
+
+	info, err := os.Stat(sb.DatabasePath)
+	if err != nil {
+		return fmt.Errorf("database does not exist: %s", sb.DatabasePath)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("database is not a regular file: %s", sb.DatabasePath)
+	}
+
+	return nil
+}
+
+// Backup snapshots the database, checks the integrity of the copy and
+// compresses it to outputPath
+func (sb *SQLiteBackup) Backup(outputPath string) (*Result, error) {
+	startTime := time.Now()
+
+	if err := sb.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Snapshot into a private directory so the archive keeps the original file name
+	tempDir, err := os.MkdirTemp(filepath.Dir(outputPath), ".sqlite-snapshot-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+	snapshotPath := filepath.Join(tempDir, filepath.Base(sb.DatabasePath))
+
+	var command string
+	if sb.method() == SQLiteMethodVacuum {
+		command = "VACUUM INTO " + quoteSQLString(snapshotPath)
+	} else {
+		command = ".backup " + quoteShellArg(snapshotPath)
+	}
+
+	fmt.Printf("Snapshotting SQLite database %s...\n", sb.DatabasePath)
+	if _, err := runSQLite(sb.DatabasePath, command); err != nil {
+		return nil, fmt.Errorf("snapshot failed: %w", err)
+	}
+
+	if err := checkSQLiteIntegrity(snapshotPath); err != nil {
+		return nil, err
+	}
+
+	snapshotInfo, err := os.Stat(snapshotPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat snapshot: %w", err)
+	}
+
+	if err := compressTarGz(snapshotPath, outputPath); err != nil {
+		os.Remove(outputPath)
+		return nil, fmt.Errorf("compression failed: %w", err)
+	}
+
+	fileInfo, err := os.Stat(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat compressed file: %w", err)
+	}
+
+	result := &Result{
+		Type:         TypeSQLite,
+		Name:         sb.Name,
+		Filename:     filepath.Base(outputPath),
+		Path:         outputPath,
+		Size:         fileInfo.Size(),
+		OriginalSize: snapshotInfo.Size(),
+		Duration:     time.Since(startTime),
+		DatabaseName: filepath.Base(sb.DatabasePath),
+		Timestamp:    startTime,
+		Compression:  CompressionGzip,
+	}
+	result.CompressionPct = result.CalculateCompressionPct()
+
+	return result, nil
+}
+
+// method returns the snapshot method, defaulting to the online backup API
+func (sb *SQLiteBackup) method() string {
+	if sb.Method == "" {
+		return SQLiteMethodBackup
+	}
+	return sb.Method
+}
+
+// RestoreSQLite replaces the database at targetPath with the snapshot in a
+// backup. The snapshot is integrity-checked, the current file is kept as
+// <target>.bak and the new one is renamed into place atomically. Applications
+// using the database must be stopped while it is replaced.
+func RestoreSQLite(backupFile string, targetPath string) error {
+	fmt.Printf("Starting restore from backup: %s\n", backupFile)
+
+	targetDir := filepath.Dir(targetPath)
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return fmt.Errorf("failed to create target directory: %w", err)
+	}
+
+	// Extract next to the target so the final rename is atomic
+	tempDir, err := os.MkdirTemp(targetDir, ".sqlite-restore-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	fmt.Printf("Extracting backup file...\n")
+	snapshot, err := extractTarGz(backupFile, tempDir)
+	if err != nil {
+		return fmt.Errorf("extraction failed: %w", err)
+	}
+
+	fmt.Printf("Checking integrity...\n")
+	if err := checkSQLiteIntegrity(snapshot); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(targetPath); err == nil {
+		if err := copyFile(targetPath, targetPath+".bak"); err != nil {
+			return fmt.Errorf("failed to keep a copy of the current database: %w", err)
+		}
+		fmt.Printf("Previous database saved as %s.bak\n", targetPath)
+	}
+
+	// A WAL or journal left from the old database would be applied to the new
+	// one; move them next to the .bak copy, which they belong to
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		if err := os.Rename(targetPath+suffix, targetPath+".bak"+suffix); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to move stale %s file: %w", suffix, err)
+		}
+	}
+
+	if err := os.Rename(snapshot, targetPath); err != nil {
+		return fmt.Errorf("failed to replace database: %w", err)
+	}
+
+	if err := syncDirectory(targetDir); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Restore completed successfully!\n")
+	fmt.Printf("   Database: %s\n", targetPath)
+	fmt.Printf("   From: %s\n", backupFile)
+
+	return nil
+}
+
+// checkSQLiteIntegrity runs PRAGMA integrity_check on a database file
+func checkSQLiteIntegrity(path string) error {
+	output, err := runSQLite(path, "PRAGMA integrity_check;")
+	if err != nil {
+		return fmt.Errorf("integrity check failed: %w", err)
+	}
+	if output != "ok" {
+		return fmt.Errorf("integrity check failed: %s", output)
+	}
+	return nil
+}
+
+// runSQLite runs a single command with the sqlite3 shell and returns its
+// trimmed output
+func runSQLite(databasePath string, command string) (string, error) {
+	cmd := exec.Command("sqlite3", "-bail", databasePath, command)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// quoteSQLString quotes a value as an SQL string literal
+func quoteSQLString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// quoteShellArg quotes an argument to a sqlite3 shell dot-command
+func quoteShellArg(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
+
+// syncDirectory fsyncs a directory so a preceding rename is durable
+func syncDirectory(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open directory %s: %w", dir, err)
+	}
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("failed to sync directory %s: %w", dir, err)
+	}
+	return nil
+}
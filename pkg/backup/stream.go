@@ -0,0 +1,129 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// compressStream gzips everything read from r into outputPath and returns the
+// number of uncompressed bytes. It lets tools that write a dump to stdout be
+// compressed without first writing the whole dump to disk.
+func compressStream(r io.Reader, outputPath string) (int64, error) {
+	outputFile, err := os.Create(outputPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer outputFile.Close()
+
+	gzipWriter := gzip.NewWriter(outputFile)
+
+	written, err := io.Copy(gzipWriter, r)
+	if err != nil {
+		return written, fmt.Errorf("failed to compress stream: %w", err)
+	}
+
+	if err := gzipWriter.Close(); err != nil {
+		return written, fmt.Errorf("failed to finish compression: %w", err)
+	}
+	if err := outputFile.Sync(); err != nil {
+		return written, fmt.Errorf("failed to sync output file: %w", err)
+	}
+
+	return written, nil
+}
+
+// decompressStream opens a gzip file written by compressStream for reading
+// The caller must close the returned reader, which also closes the file.
+func decompressStream(inputPath string) (io.ReadCloser, error) {
+	inputFile, err := os.Open(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup file: %w", err)
+	}
+
+	gzipReader, err := gzip.NewReader(inputFile)
+	if err != nil {
+		inputFile.Close()
+		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+
+	return &gzipFile{Reader: gzipReader, file: inputFile}, nil
+}
+
+// gzipFile closes both the gzip reader and the underlying file
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipFile) Close() error {
+	err := g.Reader.Close()
+	if closeErr := g.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// compressDirTarGz archives the regular files directly inside dir into a
+// tar.gz at outputPath and returns their total size. Unlike compressTarGz it
+// takes several files, which extractTarGz restores side by side.
+func compressDirTarGz(dir string, outputPath string) (int64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	outputFile, err := os.Create(outputPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer outputFile.Close()
+
+	gzipWriter := gzip.NewWriter(outputFile)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	var total int64
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return 0, fmt.Errorf("failed to stat %s: %w", entry.Name(), err)
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return 0, fmt.Errorf("failed to create tar header: %w", err)
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return 0, fmt.Errorf("failed to write tar header: %w", err)
+		}
+
+		file, err := os.Open(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return 0, fmt.Errorf("failed to open %s: %w", entry.Name(), err)
+		}
+		_, err = io.Copy(tarWriter, file)
+		file.Close()
+		if err != nil {
+			return 0, fmt.Errorf("failed to archive %s: %w", entry.Name(), err)
+		}
+		total += info.Size()
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return 0, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return 0, fmt.Errorf("failed to finish compression: %w", err)
+	}
+	if err := outputFile.Sync(); err != nil {
+		return 0, fmt.Errorf("failed to sync output file: %w", err)
+	}
+
+	return total, nil
+}
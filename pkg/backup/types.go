@@ -6,10 +6,18 @@ import "time"
 type BackupType string
 
 const (
-	TypePostgreSQL BackupType = "postgres"
-	TypeMySQL      BackupType = "mysql"
-	TypeFiles      BackupType = "files"
-	TypeDirectory  BackupType = "directory"
+	TypePostgreSQL       BackupType = "postgres"
+	TypePostgresPhysical BackupType = "postgres-physical"
+	TypeMySQL            BackupType = "mysql"
+	TypeMongoDB          BackupType = "mongodb"
+	TypeRedis            BackupType = "redis"
+	TypeSQLite           BackupType = "sqlite"
+	TypeEtcd             BackupType = "etcd"
+	TypeKubernetes       BackupType = "kubernetes"
+	TypeGit              BackupType = "git"
+	TypeExec             BackupType = "exec"
+	TypeFiles            BackupType = "files"
+	TypeDirectory        BackupType = "directory"
 )
 
 // Backuper is the common interface for all backup types
@@ -20,16 +28,23 @@ type Backuper interface {
 
 // Result contains information about a completed backup
 type Result struct {
-	Type           BackupType
-	Filename       string
-	Path           string
-	Size           int64
-	OriginalSize   int64
-	Duration       time.Duration
-	FilesIncluded  int64
-	DatabaseName   string // For database backups
-	Timestamp      time.Time
-	CompressionPct float64
+	Type            BackupType
+	Name            string
+	Filename        string
+	Path            string
+	Size            int64
+	OriginalSize    int64
+	Duration        time.Duration
+	FilesIncluded   int64
+	DatabaseName    string // For database backups
+	Timestamp       time.Time
+	CompressionPct  float64
+	Compression     string // Compression algorithm, e.g. "gzip"
+	Encrypted       bool
+	EncryptionKeyID string            // Fingerprint of the encryption key, see encryption.KeyID
+	Hostname        string            // Host the backup was taken on
+	ToolVersion     string            // Orchestrator version that created the backup
+	Extra           map[string]string // Type-specific metadata (e.g. MetaRevision) and metadata reported by plugins
 }
 
 // CalculateCompressionPct calculates compression percentage
@@ -0,0 +1,316 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MetaWALSHA256 is the object metadata key holding the hex SHA-256 of an
+// archived WAL file before compression and encryption
+const MetaWALSHA256 = "wal-sha256"
+
+// pgRecoveryTimeFormat is how recovery_target_time is written to the config
+const pgRecoveryTimeFormat = "2006-01-02 15:04:05.999999-07:00"
+
+// walFilePattern matches the files PostgreSQL archives: segments, partial
+// segments, timeline history files and backup history files
+var walFilePattern = regexp.MustCompile(`^([0-9A-F]{24}(\.partial|\.[0-9A-F]{8}\.backup)?|[0-9A-F]{8}\.history)$`)
+
+// IsWALFileName reports whether name is a file PostgreSQL hands to
+// archive_command or asks restore_command for
+func IsWALFileName(name string) bool {
+	return walFilePattern.MatchString(name)
+}
+
+// CompressWAL gzips a WAL file to outputPath and returns the hex SHA-256 of
+// the uncompressed file
+func CompressWAL(walPath string, outputPath string) (string, error) {
+	file, err := os.Open(walPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open WAL file: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := compressStream(io.TeeReader(file, hash), outputPath); err != nil {
+		os.Remove(outputPath)
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// WALFileSHA256 returns the hex SHA-256 of a WAL file, as recorded by
+// CompressWAL
+func WALFileSHA256(walPath string) (string, error) {
+	file, err := os.Open(walPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open WAL file: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to read WAL file: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// DecompressWAL writes the WAL file compressed by CompressWAL to outputPath
+// The file is written under a temporary name and renamed into place, so
+// PostgreSQL never sees a partial segment.
+func DecompressWAL(inputPath string, outputPath string) error {
+	reader, err := decompressStream(inputPath)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	tempPath := outputPath + ".orchestrator-tmp"
+	output, err := os.OpenFile(tempPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", tempPath, err)
+	}
+
+	_, err = io.Copy(output, reader)
+	if err == nil {
+		err = output.Sync()
+	}
+	if closeErr := output.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to decompress WAL file: %w", err)
+	}
+
+	if err := os.Rename(tempPath, outputPath); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to move WAL file into place: %w", err)
+	}
+	return nil
+}
+
+// WALFetchCommand builds a restore_command that runs wal-fetch with the given
+// executable and extra arguments. PostgreSQL substitutes %f and %p.
+func WALFetchCommand(executable string, args ...string) string {
+	parts := []string{quotePosixArg(executable), "wal-fetch", "%f", `"%p"`}
+	for _, arg := range args {
+		parts = append(parts, quotePosixArg(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// PostgresPITROptions controls a point-in-time recovery
+type PostgresPITROptions struct {
+	DataDir        string    // Data directory to lay the base backup out in; must be empty
+	TargetTime     time.Time // Recovery stops at this time; all archived WAL is replayed if zero
+	RestoreCommand string    // restore_command fetching archived WAL, see WALFetchCommand
+}
+
+// RestorePostgresPITR lays a base backup out into an empty data directory and
+// configures PostgreSQL to replay archived WAL up to the target time
+// The base backup is a tar.gz of the data directory, as written by a
+// postgres-physical backup or `pg_basebackup -Ft -z`. Recovery runs when the
+// server is started and the server is promoted once the target is reached.
+// PostgreSQL 12 or newer is required.
+func RestorePostgresPITR(backupFile string, opts PostgresPITROptions) error {
+	fmt.Printf("Starting point-in-time recovery from base backup: %s\n", backupFile)
+
+	if opts.RestoreCommand == "" {
+		return fmt.Errorf("restore command is required")
+	}
+	if err := layoutBaseBackup(backupFile, opts.DataDir); err != nil {
+		return err
+	}
+
+	major, err := pgDataDirVersion(opts.DataDir)
+	if err != nil {
+		return err
+	}
+	if major < 12 {
+		return fmt.Errorf("point-in-time recovery needs PostgreSQL 12 or newer, base backup is from %d", major)
+	}
+
+	if err := writeRecoveryConfig(opts); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Data directory prepared for recovery!\n")
+	fmt.Printf("   Data directory: %s\n", opts.DataDir)
+	if opts.TargetTime.IsZero() {
+		fmt.Printf("   Recovery target: end of archived WAL\n")
+	} else {
+		fmt.Printf("   Recovery target: %s\n", opts.TargetTime.Format(pgRecoveryTimeFormat))
+	}
+	fmt.Printf("   From: %s\n", backupFile)
+	fmt.Printf("\nStart PostgreSQL on this data directory to replay WAL; it is promoted once\n")
+	fmt.Printf("the target is reached. Watch the server log for \"recovery stopping\".\n")
+
+	return nil
+}
+
+// writeRecoveryConfig appends the recovery settings to postgresql.auto.conf
+// and creates recovery.signal
+func writeRecoveryConfig(opts PostgresPITROptions) error {
+	var b strings.Builder
+	b.WriteString("\n# Point-in-time recovery configured by orchestrator restore --pitr\n")
+	fmt.Fprintf(&b, "restore_command = %s\n", quotePgConfValue(opts.RestoreCommand))
+	if !opts.TargetTime.IsZero() {
+		fmt.Fprintf(&b, "recovery_target_time = %s\n", quotePgConfValue(opts.TargetTime.Format(pgRecoveryTimeFormat)))
+	}
+	b.WriteString("recovery_target_action = 'promote'\n")
+
+	autoConf := filepath.Join(opts.DataDir, "postgresql.auto.conf")
+	file, err := os.OpenFile(autoConf, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", autoConf, err)
+	}
+	if _, err := file.WriteString(b.String()); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", autoConf, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", autoConf, err)
+	}
+
+	signal := filepath.Join(opts.DataDir, "recovery.signal")
+	if err := os.WriteFile(signal, nil, 0600); err != nil {
+		return fmt.Errorf("failed to create recovery.signal: %w", err)
+	}
+	return nil
+}
+
+// pgDataDirVersion returns the major version from a data directory's PG_VERSION
+func pgDataDirVersion(dataDir string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(dataDir, "PG_VERSION"))
+	if err != nil {
+		return 0, fmt.Errorf("not a PostgreSQL data directory backup (no PG_VERSION): %w", err)
+	}
+	major, err := strconv.Atoi(strings.SplitN(strings.TrimSpace(string(data)), ".", 2)[0])
+	if err != nil {
+		return 0, fmt.Errorf("unexpected PG_VERSION: %s", strings.TrimSpace(string(data)))
+	}
+	return major, nil
+}
+
+// ensureEmptyDir creates dir with the permissions PostgreSQL requires, or
+// checks that an existing dir is empty
+func ensureEmptyDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("failed to create data directory: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read data directory: %w", err)
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("data directory %s is not empty; stop PostgreSQL and move it away first", dir)
+	}
+	return os.Chmod(dir, 0700)
+}
+
+// extractTarGzTree extracts a .tar.gz into destDir keeping its directory
+// structure and returns the total size of the extracted files. Unlike
+// extractTarGz it restores directories and symlinks, and rejects entries
+// that would land outside destDir.
+func extractTarGzTree(tarGzPath string, destDir string) (int64, error) {
+	file, err := os.Open(tarGzPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open tar.gz file: %w", err)
+	}
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create gzip reader: %w", err)
+	}
+	defer gzipReader.Close()
+
+	return extractTarTree(tar.NewReader(gzipReader), destDir)
+}
+
+// extractTarTree extracts a tar stream into destDir, see extractTarGzTree
+func extractTarTree(tarReader *tar.Reader, destDir string) (int64, error) {
+	var total int64
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return total, fmt.Errorf("failed to read tar header: %w", err)
+		}
+
+		name := filepath.Clean(header.Name)
+		if name == "." {
+			continue
+		}
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return total, fmt.Errorf("refusing to extract %s outside the target directory", header.Name)
+		}
+		target := filepath.Join(destDir, name)
+		mode := os.FileMode(header.Mode).Perm()
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0700); err != nil {
+				return total, fmt.Errorf("failed to create directory %s: %w", name, err)
+			}
+			if err := os.Chmod(target, mode|0700); err != nil {
+				return total, fmt.Errorf("failed to set permissions on %s: %w", name, err)
+			}
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+				return total, fmt.Errorf("failed to create directory for %s: %w", name, err)
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return total, fmt.Errorf("failed to create symlink %s: %w", name, err)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+				return total, fmt.Errorf("failed to create directory for %s: %w", name, err)
+			}
+			outFile, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+			if err != nil {
+				return total, fmt.Errorf("failed to create %s: %w", name, err)
+			}
+			written, err := io.Copy(outFile, tarReader)
+			outFile.Close()
+			if err != nil {
+				return total, fmt.Errorf("failed to extract %s: %w", name, err)
+			}
+			total += written
+		}
+	}
+
+	return total, nil
+}
+
+// quotePgConfValue quotes a value for postgresql.conf
+func quotePgConfValue(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// quotePosixArg quotes an argument for sh if it contains special characters
+func quotePosixArg(value string) string {
+	if value != "" && strings.IndexFunc(value, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,@+", r))
+	}) < 0 {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,184 @@
+package backup
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// assertGolden compares got with testdata/name, or rewrites it with -update
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the golden file:\n got:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestIsWALFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "000000010000000000000001", want: true},
+		{name: "00000002000000A3000000FF", want: true},
+		{name: "000000010000000000000001.partial", want: true},
+		{name: "00000002.history", want: true},
+		{name: "000000010000000000000002.00000028.backup", want: true},
+
+		{name: "00000001000000000000001", want: false},            // 23 digits
+		{name: "0000000100000000000000011", want: false},          // 25 digits
+		{name: "00000001000000000000000a", want: false},           // lower case
+		{name: "000000010000000000000001.gz", want: false},        // archived object, not a WAL file
+		{name: "000000010000000000000001.encrypted", want: false}, // archived object, not a WAL file
+		{name: "000000010000000000000002.backup", want: false},    // backup label without offset
+		{name: "0000002.history", want: false},
+		{name: "00000002.history.tmp", want: false},
+		{name: "archive_status", want: false},
+		{name: "../000000010000000000000001", want: false},
+		{name: "", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := IsWALFileName(tt.name); got != tt.want {
+			t.Errorf("IsWALFileName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWALFetchCommand(t *testing.T) {
+	tests := []struct {
+		name       string
+		executable string
+		args       []string
+		golden     string
+	}{
+		{
+			name:       "s3",
+			executable: "/usr/local/bin/orchestrator",
+			args:       []string{"--name", "main", "--backend", "s3", "--bucket", "dr-backups", "--s3-endpoint", "https://minio.internal:9000"},
+			golden:     "restore_command_s3.txt",
+		},
+		{
+			name:       "quoted",
+			executable: "/opt/dr tools/orchestrator",
+			args:       []string{"--name", "it's main", "--backend", "filesystem", "--fs-root", "/mnt/backups; rm -rf /"},
+			golden:     "restore_command_quoted.txt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertGolden(t, tt.golden, []byte(WALFetchCommand(tt.executable, tt.args...)+"\n"))
+		})
+	}
+}
+
+func TestWriteRecoveryConfig(t *testing.T) {
+	restoreCommand := WALFetchCommand("/usr/local/bin/orchestrator", "--name", "main", "--backend", "s3", "--bucket", "dr-backups")
+	existing := "# Do not edit this file manually!\n# It will be overwritten by the ALTER SYSTEM command.\nwork_mem = '64MB'\n"
+
+	tests := []struct {
+		name       string
+		targetTime time.Time
+		golden     string
+	}{
+		{
+			name:       "target time",
+			targetTime: time.Date(2025, 12, 9, 9, 26, 58, 123456000, time.FixedZone("CET", 3600)),
+			golden:     "postgresql.auto.conf.target-time",
+		},
+		{
+			name:   "end of archived WAL",
+			golden: "postgresql.auto.conf.end-of-wal",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dataDir := t.TempDir()
+			autoConf := filepath.Join(dataDir, "postgresql.auto.conf")
+			if err := os.WriteFile(autoConf, []byte(existing), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			err := writeRecoveryConfig(PostgresPITROptions{
+				DataDir:        dataDir,
+				TargetTime:     tt.targetTime,
+				RestoreCommand: restoreCommand,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := os.ReadFile(autoConf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertGolden(t, tt.golden, got)
+
+			signal, err := os.ReadFile(filepath.Join(dataDir, "recovery.signal"))
+			if err != nil {
+				t.Fatalf("recovery.signal not created: %v", err)
+			}
+			if len(signal) != 0 {
+				t.Errorf("recovery.signal = %q, want an empty file", signal)
+			}
+		})
+	}
+}
+
+func TestCompressWALRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	walPath := filepath.Join(dir, "000000010000000000000001")
+	segment := bytes.Repeat([]byte{0xd1, 0x10, 0x06, 0x00}, 4096)
+	if err := os.WriteFile(walPath, segment, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := CompressWAL(walPath, walPath+".gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileSum, err := WALFileSHA256(walPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != fileSum {
+		t.Errorf("CompressWAL recorded %s, WALFileSHA256 returned %s", sum, fileSum)
+	}
+
+	restored := filepath.Join(dir, "pg_wal", "RECOVERYXLOG")
+	if err := os.Mkdir(filepath.Dir(restored), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := DecompressWAL(walPath+".gz", restored); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, segment) {
+		t.Error("decompressed segment differs from the original")
+	}
+	if _, err := os.Stat(restored + ".orchestrator-tmp"); !os.IsNotExist(err) {
+		t.Error("temporary file left behind")
+	}
+}
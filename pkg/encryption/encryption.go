@@ -6,6 +6,7 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
@@ -162,6 +163,18 @@ func KeyToPassword(base64Key string) (string, error) {
 	return base64Key, nil
 }
 
+// keyIDSalt keeps key IDs apart from the keys derived for encryption
+const keyIDSalt = "cloud-dr-orchestrator/key-id"
+
+// KeyID returns a short, non-secret fingerprint of an encryption key
+// It identifies which key a backup was encrypted with without revealing it.
+// The ID is published in object metadata, so it is derived with the same
+// PBKDF2 cost as the encryption key: guessing a password from its ID is no
+// cheaper than guessing it from the encrypted backup.
+func KeyID(password string) string {
+	return hex.EncodeToString(pbkdf2.Key([]byte(password), []byte(keyIDSalt), Iterations, 8, sha256.New))
+}
+
 // IsEncrypted checks if a file appears to be encrypted
 // This is a simple heuristic based on file extension
 func IsEncrypted(filePath string) bool {
@@ -0,0 +1,17 @@
+package encryption
+
+import "testing"
+
+func TestKeyID(t *testing.T) {
+	id := KeyID("correct horse battery staple")
+
+	if len(id) != 16 {
+		t.Errorf("KeyID length = %d, want 16 hex characters", len(id))
+	}
+	if KeyID("correct horse battery staple") != id {
+		t.Error("KeyID is not deterministic")
+	}
+	if KeyID("correct horse battery stapler") == id {
+		t.Error("different keys have the same KeyID")
+	}
+}
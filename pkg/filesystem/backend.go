@@ -0,0 +1,160 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
+)
+
+// Ensure Client satisfies the storage interfaces
+var (
+	_ storage.Backend        = (*Client)(nil)
+	_ storage.FileUploader   = (*Client)(nil)
+	_ storage.FileDownloader = (*Client)(nil)
+)
+
+// Location returns the file:// URI of the root directory
+func (c *Client) Location() string {
+	return "file://" + filepath.ToSlash(c.rootPath)
+}
+
+// Put atomically writes body to the object path. size is ignored.
+// Metadata is kept in a hidden JSON file next to the object. The object is
+// staged first and its metadata put in place before the object is renamed
+// over the old one, so a crash can leave the new metadata next to the old
+// object for the instant between the two renames but never a new object
+// without its metadata.
+func (c *Client) Put(ctx context.Context, objectName string, body io.Reader, size int64, metadata map[string]string) (*storage.ObjectInfo, error) {
+	path, err := c.objectPath(objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	staged, err := stageFile(ctx, path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store object %s: %w", objectName, err)
+	}
+	defer staged.discard()
+
+	previous, err := os.ReadFile(metaPath(path))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read metadata for %s: %w", objectName, err)
+	}
+
+	if err := writeMeta(ctx, path, metadata); err != nil {
+		return nil, fmt.Errorf("failed to store metadata for %s: %w", objectName, err)
+	}
+
+	if err := staged.commit(); err != nil {
+		// The old object is still in place; give it its metadata back
+		if previous != nil {
+			writeAtomic(ctx, metaPath(path), bytes.NewReader(previous))
+		} else {
+			os.Remove(metaPath(path))
+		}
+		return nil, fmt.Errorf("failed to store object %s: %w", objectName, err)
+	}
+
+	return c.Stat(ctx, objectName)
+}
+
+// writeMeta replaces the metadata file of the object at path, removing it if
+// there is no metadata so none is left behind from an overwritten object
+func writeMeta(ctx context.Context, path string, metadata map[string]string) error {
+	if len(metadata) == 0 {
+		if err := os.Remove(metaPath(path)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(storage.NormalizeMetadata(metadata), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	_, err = writeAtomic(ctx, metaPath(path), bytes.NewReader(data))
+	return err
+}
+
+// Get opens the object file for reading
+func (c *Client) Get(ctx context.Context, objectName string) (io.ReadCloser, *storage.ObjectInfo, error) {
+	path, err := c.objectPath(objectName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open object %s: %w", objectName, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to stat object %s: %w", objectName, err)
+	}
+
+	return file, &storage.ObjectInfo{
+		Name:         objectName,
+		Size:         info.Size(),
+		LastModified: info.ModTime(),
+		ETag:         etag(info),
+	}, nil
+}
+
+// List returns all objects whose name starts with prefix
+func (c *Client) List(ctx context.Context, prefix string) ([]storage.ObjectInfo, error) {
+	return c.ListObjects(ctx, prefix)
+}
+
+// Delete removes the object file
+func (c *Client) Delete(ctx context.Context, objectName string) error {
+	path, err := c.objectPath(objectName)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to delete object %s: %w", objectName, err)
+	}
+	if err := os.Remove(metaPath(path)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete metadata for %s: %w", objectName, err)
+	}
+
+	return syncDir(filepath.Dir(path))
+}
+
+// Stat returns information about the object file
+func (c *Client) Stat(ctx context.Context, objectName string) (*storage.ObjectInfo, error) {
+	path, err := c.objectPath(objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat object %s: %w", objectName, err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("object %s is not a regular file", objectName)
+	}
+
+	meta, err := readMeta(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat object %s: %w", objectName, err)
+	}
+
+	return &storage.ObjectInfo{
+		Name:         objectName,
+		Size:         info.Size(),
+		LastModified: info.ModTime(),
+		ETag:         etag(info),
+		Metadata:     meta,
+	}, nil
+}
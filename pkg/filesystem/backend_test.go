@@ -0,0 +1,201 @@
+package filesystem
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
+)
+
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	client, err := NewClient(Config{RootPath: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func put(t *testing.T, client *Client, name string, content string, metadata map[string]string) {
+	t.Helper()
+	if _, err := client.Put(context.Background(), name, strings.NewReader(content), int64(len(content)), metadata); err != nil {
+		t.Fatalf("Put(%s): %v", name, err)
+	}
+}
+
+func TestObjectPath(t *testing.T) {
+	client := newTestClient(t)
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "backups/2025/12/db.tar.gz", want: "backups/2025/12/db.tar.gz"},
+		{name: "/backups/db.tar.gz", want: "backups/db.tar.gz"},
+		{name: "backups/../db.tar.gz", want: "db.tar.gz"},
+		{name: "", wantErr: true},
+		{name: ".", wantErr: true},
+		{name: "..", wantErr: true},
+		{name: "../outside.tar.gz", wantErr: true},
+		{name: "backups/../../outside.tar.gz", wantErr: true},
+		{name: "/../outside.tar.gz", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := client.objectPath(tt.name)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("objectPath(%q) = %s, want error", tt.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("objectPath(%q): %v", tt.name, err)
+			continue
+		}
+		if want := filepath.Join(client.rootPath, filepath.FromSlash(tt.want)); got != want {
+			t.Errorf("objectPath(%q) = %s, want %s", tt.name, got, want)
+		}
+	}
+}
+
+// TestBackendContract exercises the storage.Backend behaviour the commands
+// rely on
+func TestBackendContract(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+
+	put(t, client, "backups/2025/12/a.tar.gz", "first", map[string]string{"Backup-Type": "postgres"})
+	put(t, client, "backups/2025/11/b.tar.gz", "second", nil)
+	put(t, client, "wal/main/000000010000000000000001.gz", "wal", nil)
+
+	t.Run("get", func(t *testing.T) {
+		body, info, err := client.Get(ctx, "backups/2025/12/a.tar.gz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer body.Close()
+		data, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "first" || info.Size != 5 {
+			t.Errorf("Get returned %q (size %d), want %q", data, info.Size, "first")
+		}
+	})
+
+	t.Run("stat returns normalized metadata", func(t *testing.T) {
+		info, err := client.Stat(ctx, "backups/2025/12/a.tar.gz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Metadata["backup-type"] != "postgres" {
+			t.Errorf("metadata = %v, want backup-type=postgres", info.Metadata)
+		}
+	})
+
+	t.Run("stat of a missing object fails", func(t *testing.T) {
+		if _, err := client.Stat(ctx, "backups/missing.tar.gz"); err == nil {
+			t.Error("Stat of a missing object succeeded")
+		}
+	})
+
+	t.Run("list is sorted and hides internal files", func(t *testing.T) {
+		objects, err := client.List(ctx, storage.BackupsPrefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, obj := range objects {
+			names = append(names, obj.Name)
+		}
+		want := "backups/2025/11/b.tar.gz,backups/2025/12/a.tar.gz"
+		if strings.Join(names, ",") != want {
+			t.Errorf("List = %v, want %s", names, want)
+		}
+	})
+
+	t.Run("walk honours bounds", func(t *testing.T) {
+		var names []string
+		opts := storage.ListOptions{Prefix: storage.BackupsPrefix, Start: "backups/2025/12/"}
+		err := storage.Walk(ctx, client, opts, func(obj storage.ObjectInfo) error {
+			names = append(names, obj.Name)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(names, ",") != "backups/2025/12/a.tar.gz" {
+			t.Errorf("Walk = %v", names)
+		}
+	})
+
+	t.Run("overwrite replaces metadata", func(t *testing.T) {
+		put(t, client, "backups/2025/12/a.tar.gz", "replaced", map[string]string{"backup-type": "mysql"})
+		info, err := client.Stat(ctx, "backups/2025/12/a.tar.gz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size != int64(len("replaced")) || info.Metadata["backup-type"] != "mysql" {
+			t.Errorf("after overwrite: size %d, metadata %v", info.Size, info.Metadata)
+		}
+
+		put(t, client, "backups/2025/12/a.tar.gz", "bare", nil)
+		info, err = client.Stat(ctx, "backups/2025/12/a.tar.gz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Metadata != nil {
+			t.Errorf("metadata of the overwritten object left behind: %v", info.Metadata)
+		}
+	})
+
+	t.Run("delete removes object and metadata", func(t *testing.T) {
+		put(t, client, "backups/2025/10/c.tar.gz", "third", map[string]string{"backup-type": "redis"})
+		if err := client.Delete(ctx, "backups/2025/10/c.tar.gz"); err != nil {
+			t.Fatal(err)
+		}
+		path, _ := client.objectPath("backups/2025/10/c.tar.gz")
+		for _, p := range []string{path, metaPath(path)} {
+			if _, err := os.Stat(p); !os.IsNotExist(err) {
+				t.Errorf("%s still exists after Delete", p)
+			}
+		}
+	})
+
+	t.Run("names outside the root are rejected", func(t *testing.T) {
+		if _, err := client.Put(ctx, "../escape.tar.gz", strings.NewReader("x"), 1, nil); err == nil {
+			t.Error("Put outside the root succeeded")
+		}
+		if _, _, err := client.Get(ctx, "../../etc/passwd"); err == nil {
+			t.Error("Get outside the root succeeded")
+		}
+	})
+}
+
+// TestPutFailureKeepsObjectAndMetadata checks that a failed overwrite leaves
+// the previous object and its metadata untouched
+func TestPutFailureKeepsObjectAndMetadata(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	put(t, client, "backups/a.tar.gz", "original", map[string]string{"backup-type": "postgres"})
+
+	body := io.MultiReader(strings.NewReader("partial"), errorReader{})
+	if _, err := client.Put(ctx, "backups/a.tar.gz", body, -1, map[string]string{"backup-type": "mysql"}); err == nil {
+		t.Fatal("Put with a failing reader succeeded")
+	}
+
+	info, err := client.Stat(ctx, "backups/a.tar.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != int64(len("original")) || info.Metadata["backup-type"] != "postgres" {
+		t.Errorf("after failed Put: size %d, metadata %v", info.Size, info.Metadata)
+	}
+	assertNoTempFiles(t, filepath.Join(client.rootPath, "backups"))
+}
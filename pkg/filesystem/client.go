@@ -0,0 +1,97 @@
+package filesystem
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// tempPrefix marks in-progress writes so they are never listed as backups
+	tempPrefix = ".orchestrator-tmp-"
+	// metaPrefix marks the hidden files holding object metadata
+	metaPrefix = ".orchestrator-meta-"
+)
+
+// Client stores backups on a local or network-mounted filesystem (NFS, USB)
+// using the same object layout as the cloud backends
+type Client struct {
+	rootPath string
+}
+
+// Config holds the configuration for the filesystem backend
+type Config struct {
+	RootPath string // Directory that plays the role of the bucket
+}
+
+// NewClient creates a new filesystem backend rooted at config.RootPath
+func NewClient(config Config) (*Client, error) {
+	if config.RootPath == "" {
+		return nil, fmt.Errorf("root path is required")
+	}
+
+	rootPath, err := filepath.Abs(config.RootPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid root path: %w", err)
+	}
+
+	info, err := os.Stat(rootPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access root path %s: %w", rootPath, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("root path is not a directory: %s", rootPath)
+	}
+
+	return &Client{rootPath: rootPath}, nil
+}
+
+// GetRootPath returns the directory backups are stored in
+func (c *Client) GetRootPath() string {
+	return c.rootPath
+}
+
+// objectPath maps an object name to a path below the root directory
+// It rejects names that would escape the root
+func (c *Client) objectPath(objectName string) (string, error) {
+	if objectName == "" {
+		return "", fmt.Errorf("object name is required")
+	}
+
+	cleaned := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(objectName, "/")))
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid object name: %s", objectName)
+	}
+
+	return filepath.Join(c.rootPath, cleaned), nil
+}
+
+// metaPath returns the path of the metadata file kept next to an object
+func metaPath(path string) string {
+	return filepath.Join(filepath.Dir(path), metaPrefix+filepath.Base(path)+".json")
+}
+
+// readMeta loads the metadata stored for the object at path
+// Objects without a metadata file have no metadata
+func readMeta(path string) (map[string]string, error) {
+	data, err := os.ReadFile(metaPath(path))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+
+	var meta map[string]string
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata: %w", err)
+	}
+	return meta, nil
+}
+
+// etag derives a weak entity tag from size and modification time
+func etag(info os.FileInfo) string {
+	return fmt.Sprintf("%x-%x", info.ModTime().UnixNano(), info.Size())
+}
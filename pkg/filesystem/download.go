@@ -0,0 +1,41 @@
+package filesystem
+
+import (
+	"context"
+	"time"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
+)
+
+// DownloadResult contains information about the downloaded file
+type DownloadResult = storage.DownloadResult
+
+// DownloadFile copies an object from the backend directory to a local file
+func (c *Client) DownloadFile(ctx context.Context, objectName string, localPath string) (*DownloadResult, error) {
+	startTime := time.Now()
+
+	body, info, err := c.Get(ctx, objectName)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	written, err := writeAtomic(ctx, localPath, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DownloadResult{
+		ObjectName:   objectName,
+		LocalPath:    localPath,
+		Size:         written,
+		Duration:     time.Since(startTime),
+		LastModified: info.LastModified,
+	}, nil
+}
+
+// DownloadBackup is a convenience function that downloads a backup file
+// It downloads from the specified object name to the local path
+func (c *Client) DownloadBackup(ctx context.Context, objectName string, localPath string) (*DownloadResult, error) {
+	return c.DownloadFile(ctx, objectName, localPath)
+}
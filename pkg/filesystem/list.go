@@ -0,0 +1,88 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
+)
+
+// ObjectInfo contains information about a stored backup file
+type ObjectInfo = storage.ObjectInfo
+
+// ListObjects lists all files below the root directory with an optional prefix
+// Object names use forward slashes regardless of the host OS
+func (c *Client) ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+
+	err := filepath.WalkDir(c.rootPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(c.rootPath, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			// Skip directories that cannot contain matching objects
+			if name != "." && !strings.HasPrefix(name+"/", prefix) && !strings.HasPrefix(prefix, name+"/") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.Type().IsRegular() || isInternal(d.Name()) || !strings.HasPrefix(name, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		objects = append(objects, ObjectInfo{
+			Name:         name,
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+			ETag:         etag(info),
+		})
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	// Match the lexicographic order returned by object stores
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Name < objects[j].Name
+	})
+
+	return objects, nil
+}
+
+// isInternal reports whether a file is an in-progress write or metadata
+// rather than a stored object
+func isInternal(name string) bool {
+	return strings.HasPrefix(name, tempPrefix) || strings.HasPrefix(name, metaPrefix)
+}
+
+// ListBackups lists all backup files (objects with 'backups/' prefix)
+func (c *Client) ListBackups(ctx context.Context) ([]ObjectInfo, error) {
+	return c.ListObjects(ctx, storage.BackupsPrefix)
+}
+
+// ListBackupsByDate lists backups for a specific year and month
+func (c *Client) ListBackupsByDate(ctx context.Context, year int, month int) ([]ObjectInfo, error) {
+	return c.ListObjects(ctx, storage.BackupDatePrefix(year, month))
+}
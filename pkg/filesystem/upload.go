@@ -0,0 +1,146 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
+)
+
+// UploadResult contains information about the uploaded file
+type UploadResult = storage.UploadResult
+
+// UploadFile copies a local file and its metadata into the backend directory
+func (c *Client) UploadFile(ctx context.Context, localPath string, objectName string, metadata map[string]string) (*UploadResult, error) {
+	startTime := time.Now()
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", localPath, err)
+	}
+	defer file.Close()
+
+	info, err := c.Put(ctx, objectName, file, -1, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UploadResult{
+		ObjectName: objectName,
+		Location:   c.Location(),
+		Size:       info.Size,
+		Duration:   time.Since(startTime),
+		ETag:       info.ETag,
+	}, nil
+}
+
+// UploadBackup is a convenience function that uploads a backup file
+// It automatically generates the object name from the local file path
+func (c *Client) UploadBackup(ctx context.Context, backupPath string) (*UploadResult, error) {
+	// Create a folder structure: backups/YYYY/MM/filename
+	objectName := storage.BackupObjectName(backupPath, time.Now())
+
+	return c.UploadFile(ctx, backupPath, objectName, nil)
+}
+
+// writeAtomic writes body to path via a temporary file in the same directory.
+// The data is fsynced before the rename and the directory after it, so a crash
+// or unplugged disk never leaves a partially written backup under its final name.
+func writeAtomic(ctx context.Context, path string, body io.Reader) (int64, error) {
+	staged, err := stageFile(ctx, path, body)
+	if err != nil {
+		return 0, err
+	}
+	defer staged.discard()
+
+	if err := staged.commit(); err != nil {
+		return 0, err
+	}
+	return staged.size, nil
+}
+
+// stagedFile is data written and fsynced under a temporary name next to its
+// final path, waiting to be renamed into place
+type stagedFile struct {
+	path    string
+	tmpPath string
+	size    int64
+}
+
+// stageFile writes body to a temporary file in the directory of path
+func stageFile(ctx context.Context, path string, body io.Reader) (*stagedFile, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	tmpFile, err := os.CreateTemp(dir, tempPrefix+filepath.Base(path)+"-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+
+	written, err := io.Copy(tmpFile, &contextReader{ctx: ctx, r: body})
+	if err != nil {
+		err = fmt.Errorf("failed to write %s: %w", path, err)
+	} else if err = tmpFile.Sync(); err != nil {
+		err = fmt.Errorf("failed to sync %s: %w", path, err)
+	}
+	if closeErr := tmpFile.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close %s: %w", path, closeErr)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+
+	return &stagedFile{path: path, tmpPath: tmpPath, size: written}, nil
+}
+
+// commit renames the staged file into place and makes the rename durable
+func (s *stagedFile) commit() error {
+	if err := os.Rename(s.tmpPath, s.path); err != nil {
+		return fmt.Errorf("failed to rename into place: %w", err)
+	}
+	s.tmpPath = ""
+
+	return syncDir(filepath.Dir(s.path))
+}
+
+// discard removes the staged file unless it has been committed
+func (s *stagedFile) discard() {
+	if s.tmpPath != "" {
+		os.Remove(s.tmpPath)
+	}
+}
+
+// syncDir fsyncs a directory so a preceding rename is durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open directory %s: %w", dir, err)
+	}
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("failed to sync directory %s: %w", dir, err)
+	}
+	return nil
+}
+
+// contextReader aborts a copy once the context is cancelled
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
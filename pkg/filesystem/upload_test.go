@@ -0,0 +1,98 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// errorReader fails every read
+type errorReader struct{}
+
+func (errorReader) Read(p []byte) (int, error) {
+	return 0, errors.New("disk unplugged")
+}
+
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), tempPrefix) {
+			t.Errorf("temporary file left behind: %s", entry.Name())
+		}
+	}
+}
+
+func TestWriteAtomic(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nested", "db.tar.gz")
+
+	written, err := writeAtomic(ctx, path, strings.NewReader("backup data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != int64(len("backup data")) {
+		t.Errorf("written = %d, want %d", written, len("backup data"))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "backup data" {
+		t.Errorf("content = %q", data)
+	}
+	assertNoTempFiles(t, filepath.Dir(path))
+}
+
+func TestWriteAtomicFailureKeepsExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "db.tar.gz")
+	if err := os.WriteFile(path, []byte("complete backup"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		ctx  func() context.Context
+		body io.Reader
+	}{
+		{
+			name: "read error",
+			ctx:  context.Background,
+			body: io.MultiReader(strings.NewReader("half a backup"), errorReader{}),
+		},
+		{
+			name: "cancelled",
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			body: strings.NewReader("new backup"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := writeAtomic(tt.ctx(), path, tt.body); err == nil {
+				t.Fatal("writeAtomic succeeded")
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != "complete backup" {
+				t.Errorf("existing file changed to %q", data)
+			}
+			assertNoTempFiles(t, dir)
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package oracle
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/common/auth"
+)
+
+// AuthMode selects how the client authenticates to OCI
+type AuthMode string
+
+const (
+	// AuthAuto picks a mode using the precedence described on NewClient
+	AuthAuto AuthMode = ""
+	// AuthConfigFile uses an API key from ~/.oci/config
+	AuthConfigFile AuthMode = "api_key"
+	// AuthSessionToken uses a session token created by `oci session authenticate`
+	AuthSessionToken AuthMode = "security_token"
+	// AuthInstancePrincipal uses the identity of the compute instance
+	AuthInstancePrincipal AuthMode = "instance_principal"
+	// AuthResourcePrincipal uses the identity of the function or job
+	AuthResourcePrincipal AuthMode = "resource_principal"
+	// AuthWorkloadIdentity uses the identity of the OKE pod's service account
+	AuthWorkloadIdentity AuthMode = "oke_workload_identity"
+	// AuthEnvironment uses an API key supplied entirely through environment variables
+	AuthEnvironment AuthMode = "env"
+)
+
+// Environment variables read by AuthEnvironment
+const (
+	EnvTenancyOCID          = "OCI_TENANCY_OCID"
+	EnvUserOCID             = "OCI_USER_OCID"
+	EnvFingerprint          = "OCI_FINGERPRINT"
+	EnvRegion               = "OCI_REGION"
+	EnvPrivateKey           = "OCI_PRIVATE_KEY"      // PEM content
+	EnvPrivateKeyPath       = "OCI_PRIVATE_KEY_PATH" // Used if OCI_PRIVATE_KEY is empty
+	EnvPrivateKeyPassphrase = "OCI_PRIVATE_KEY_PASSPHRASE"
+)
+
+// ParseAuthMode validates an auth mode name
+func ParseAuthMode(name string) (AuthMode, error) {
+	switch mode := AuthMode(strings.ToLower(name)); mode {
+	case AuthAuto, AuthConfigFile, AuthSessionToken, AuthInstancePrincipal,
+		AuthResourcePrincipal, AuthWorkloadIdentity, AuthEnvironment:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unsupported OCI auth mode: %s (supported: %s, %s, %s, %s, %s, %s)", name,
+			AuthConfigFile, AuthSessionToken, AuthInstancePrincipal, AuthResourcePrincipal, AuthWorkloadIdentity, AuthEnvironment)
+	}
+}
+
+// resolveAuthMode applies the auto-detection precedence:
+//  1. Config.AuthMode
+//  2. OCI_CLI_AUTH environment variable
+//  3. Resource principal, if OCI_RESOURCE_PRINCIPAL_VERSION is set
+//  4. Environment variables, if OCI_TENANCY_OCID, OCI_USER_OCID and OCI_FINGERPRINT are set
+//  5. Config file, if it was given explicitly or exists at the default location
+//  6. Instance principal, if the instance metadata endpoint answers
+func resolveAuthMode(config Config, explicitConfigFile bool) (AuthMode, error) {
+	if config.AuthMode != AuthAuto {
+		return config.AuthMode, nil
+	}
+	if env := os.Getenv("OCI_CLI_AUTH"); env != "" {
+		return ParseAuthMode(env)
+	}
+	if os.Getenv("OCI_RESOURCE_PRINCIPAL_VERSION") != "" {
+		return AuthResourcePrincipal, nil
+	}
+	if os.Getenv(EnvTenancyOCID) != "" && os.Getenv(EnvUserOCID) != "" && os.Getenv(EnvFingerprint) != "" {
+		return AuthEnvironment, nil
+	}
+	if explicitConfigFile {
+		return AuthConfigFile, nil
+	}
+	if _, err := os.Stat(config.ConfigFilePath); err == nil {
+		return AuthConfigFile, nil
+	}
+	if onOCIInstance() {
+		return AuthInstancePrincipal, nil
+	}
+	return "", fmt.Errorf("no OCI credentials found: config file %s not found and not running on an OCI instance (run \"oci setup config\" or select an auth mode)", config.ConfigFilePath)
+}
+
+// instanceMetadataURL is the OCI instance metadata service
+var instanceMetadataURL = "http://169.254.169.254/opc/v2/instance/"
+
+// onOCIInstance reports whether the instance metadata service answers, so
+// instance principal is only picked on OCI compute instances. Tests replace it.
+var onOCIInstance = instanceMetadataReachable
+
+// instanceMetadataReachable asks the instance metadata service for the instance
+func instanceMetadataReachable() bool {
+	// Link-local and never through a proxy
+	client := &http.Client{Timeout: 2 * time.Second, Transport: &http.Transport{}}
+
+	request, err := http.NewRequest(http.MethodGet, instanceMetadataURL, nil)
+	if err != nil {
+		return false
+	}
+	request.Header.Set("Authorization", "Bearer Oracle")
+
+	response, err := client.Do(request)
+	if err != nil {
+		return false
+	}
+	response.Body.Close()
+	return response.StatusCode == http.StatusOK
+}
+
+// newConfigurationProvider builds the OCI configuration provider for the
+// resolved auth mode
+func newConfigurationProvider(config Config, explicitConfigFile bool) (common.ConfigurationProvider, AuthMode, error) {
+	mode, err := resolveAuthMode(config, explicitConfigFile)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var provider common.ConfigurationProvider
+	switch mode {
+	case AuthConfigFile:
+		provider, err = common.ConfigurationProviderFromFileWithProfile(config.ConfigFilePath, config.Profile, "")
+	case AuthSessionToken:
+		provider, err = common.ConfigurationProviderForSessionTokenWithProfile(config.ConfigFilePath, config.Profile, "")
+	case AuthInstancePrincipal:
+		provider, err = auth.InstancePrincipalConfigurationProvider()
+	case AuthResourcePrincipal:
+		provider, err = auth.ResourcePrincipalConfigurationProvider()
+	case AuthWorkloadIdentity:
+		provider, err = auth.OkeWorkloadIdentityConfigurationProvider()
+	case AuthEnvironment:
+		provider, err = environmentConfigurationProvider()
+	default:
+		_, err = ParseAuthMode(string(mode))
+	}
+	if err != nil {
+		return nil, mode, fmt.Errorf("failed to load OCI %s credentials: %w", mode, err)
+	}
+
+	return provider, mode, nil
+}
+
+// environmentConfigurationProvider builds an API key provider from the
+// OCI_* environment variables, so containers need no config file on disk
+func environmentConfigurationProvider() (common.ConfigurationProvider, error) {
+	required := []string{EnvTenancyOCID, EnvUserOCID, EnvFingerprint, EnvRegion}
+	for _, name := range required {
+		if os.Getenv(name) == "" {
+			return nil, fmt.Errorf("environment variable %s is not set", name)
+		}
+	}
+
+	privateKey := os.Getenv(EnvPrivateKey)
+	if privateKey == "" {
+		keyPath := os.Getenv(EnvPrivateKeyPath)
+		if keyPath == "" {
+			return nil, fmt.Errorf("either %s or %s must be set", EnvPrivateKey, EnvPrivateKeyPath)
+		}
+		data, err := os.ReadFile(keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key: %w", err)
+		}
+		privateKey = string(data)
+	}
+
+	var passphrase *string
+	if value := os.Getenv(EnvPrivateKeyPassphrase); value != "" {
+		passphrase = &value
+	}
+
+	return common.NewRawConfigurationProvider(
+		os.Getenv(EnvTenancyOCID),
+		os.Getenv(EnvUserOCID),
+		os.Getenv(EnvRegion),
+		os.Getenv(EnvFingerprint),
+		privateKey,
+		passphrase,
+	), nil
+}
@@ -0,0 +1,103 @@
+package oracle
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveAuthMode(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "config")
+	if err := os.WriteFile(existing, []byte("[DEFAULT]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing")
+
+	apiKeyEnv := map[string]string{EnvTenancyOCID: "ocid1.tenancy", EnvUserOCID: "ocid1.user", EnvFingerprint: "aa:bb"}
+
+	tests := []struct {
+		name       string
+		mode       AuthMode
+		env        map[string]string
+		configFile string
+		explicit   bool
+		onInstance bool
+		want       AuthMode
+		wantErr    string
+	}{
+		{name: "explicit mode wins", mode: AuthSessionToken, env: map[string]string{"OCI_CLI_AUTH": "instance_principal"}, configFile: existing, want: AuthSessionToken},
+		{name: "OCI_CLI_AUTH", env: map[string]string{"OCI_CLI_AUTH": "Resource_Principal", "OCI_RESOURCE_PRINCIPAL_VERSION": "2.2"}, configFile: existing, want: AuthResourcePrincipal},
+		{name: "invalid OCI_CLI_AUTH", env: map[string]string{"OCI_CLI_AUTH": "password"}, configFile: existing, wantErr: "unsupported OCI auth mode"},
+		{name: "resource principal before api key variables", env: merge(apiKeyEnv, map[string]string{"OCI_RESOURCE_PRINCIPAL_VERSION": "2.2"}), configFile: existing, want: AuthResourcePrincipal},
+		{name: "api key variables before config file", env: apiKeyEnv, configFile: existing, want: AuthEnvironment},
+		{name: "incomplete api key variables", env: map[string]string{EnvTenancyOCID: "ocid1.tenancy", EnvUserOCID: "ocid1.user"}, configFile: existing, want: AuthConfigFile},
+		{name: "default config file exists", configFile: existing, onInstance: true, want: AuthConfigFile},
+		{name: "explicit config file is used even if missing", configFile: missing, explicit: true, onInstance: true, want: AuthConfigFile},
+		{name: "instance principal on an instance", configFile: missing, onInstance: true, want: AuthInstancePrincipal},
+		{name: "nothing found off an instance", configFile: missing, wantErr: "config file " + missing + " not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"OCI_CLI_AUTH", "OCI_RESOURCE_PRINCIPAL_VERSION", EnvTenancyOCID, EnvUserOCID, EnvFingerprint} {
+				t.Setenv(name, tt.env[name])
+			}
+			probe := onOCIInstance
+			t.Cleanup(func() { onOCIInstance = probe })
+			onOCIInstance = func() bool { return tt.onInstance }
+
+			got, err := resolveAuthMode(Config{AuthMode: tt.mode, ConfigFilePath: tt.configFile}, tt.explicit)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("mode = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstanceMetadataReachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The v2 endpoint rejects requests without this header
+		if r.Header.Get("Authorization") != "Bearer Oracle" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"id": "ocid1.instance.oc1..x"}`))
+	}))
+	defer server.Close()
+
+	url := instanceMetadataURL
+	t.Cleanup(func() { instanceMetadataURL = url })
+
+	instanceMetadataURL = server.URL + "/opc/v2/instance/"
+	if !instanceMetadataReachable() {
+		t.Error("metadata service not detected")
+	}
+
+	server.Close()
+	if instanceMetadataReachable() {
+		t.Error("unreachable metadata service detected")
+	}
+}
+
+func merge(maps ...map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for _, m := range maps {
+		for key, value := range m {
+			merged[key] = value
+		}
+	}
+	return merged
+}
@@ -0,0 +1,151 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+)
+
+// Ensure Client satisfies the storage interfaces
+var (
+	_ storage.Backend        = (*Client)(nil)
+	_ storage.Walker         = (*Client)(nil)
+	_ storage.FileUploader   = (*Client)(nil)
+	_ storage.FileDownloader = (*Client)(nil)
+	_ storage.UploadResumer  = (*Client)(nil)
+)
+
+// Location returns the oci:// URI of the configured bucket
+func (c *Client) Location() string {
+	return fmt.Sprintf("oci://%s/%s", c.namespace, c.bucketName)
+}
+
+// Put streams body into an object in the bucket
+func (c *Client) Put(ctx context.Context, objectName string, body io.Reader, size int64, metadata map[string]string) (*storage.ObjectInfo, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("content length is required for OCI uploads")
+	}
+
+	request := objectstorage.PutObjectRequest{
+		NamespaceName: &c.namespace,
+		BucketName:    &c.bucketName,
+		ObjectName:    &objectName,
+		ContentLength: &size,
+		PutObjectBody: io.NopCloser(body),
+		OpcMeta:       objectMeta(metadata, ""),
+	}
+
+	response, err := c.objectStorageClient.PutObject(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload object %s: %w", objectName, err)
+	}
+
+	info := &storage.ObjectInfo{
+		Name: objectName,
+		Size: size,
+	}
+	if response.ETag != nil {
+		info.ETag = *response.ETag
+	}
+	if response.LastModified != nil {
+		info.LastModified = response.LastModified.Time
+	}
+
+	return info, nil
+}
+
+// Get opens an object in the bucket for reading
+func (c *Client) Get(ctx context.Context, objectName string) (io.ReadCloser, *storage.ObjectInfo, error) {
+	request := objectstorage.GetObjectRequest{
+		NamespaceName: &c.namespace,
+		BucketName:    &c.bucketName,
+		ObjectName:    &objectName,
+	}
+
+	response, err := c.objectStorageClient.GetObject(ctx, request)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download object %s: %w", objectName, err)
+	}
+
+	info := &storage.ObjectInfo{Name: objectName}
+	if response.ContentLength != nil {
+		info.Size = *response.ContentLength
+	}
+	if response.ETag != nil {
+		info.ETag = *response.ETag
+	}
+	if response.LastModified != nil {
+		info.LastModified = response.LastModified.Time
+	}
+
+	return response.Content, info, nil
+}
+
+// List returns all objects whose name starts with prefix
+func (c *Client) List(ctx context.Context, prefix string) ([]storage.ObjectInfo, error) {
+	return c.ListObjects(ctx, prefix)
+}
+
+// Delete removes an object from the bucket
+func (c *Client) Delete(ctx context.Context, objectName string) error {
+	request := objectstorage.DeleteObjectRequest{
+		NamespaceName: &c.namespace,
+		BucketName:    &c.bucketName,
+		ObjectName:    &objectName,
+	}
+
+	if _, err := c.objectStorageClient.DeleteObject(ctx, request); err != nil {
+		return fmt.Errorf("failed to delete object %s: %w", objectName, err)
+	}
+
+	return nil
+}
+
+// Stat returns information about an object using a HEAD request
+func (c *Client) Stat(ctx context.Context, objectName string) (*storage.ObjectInfo, error) {
+	request := objectstorage.HeadObjectRequest{
+		NamespaceName: &c.namespace,
+		BucketName:    &c.bucketName,
+		ObjectName:    &objectName,
+	}
+
+	response, err := c.objectStorageClient.HeadObject(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat object %s: %w", objectName, err)
+	}
+
+	info := &storage.ObjectInfo{
+		Name:          objectName,
+		StorageTier:   string(response.StorageTier),
+		ArchivalState: string(response.ArchivalState),
+		Metadata:      normalizeMeta(response.OpcMeta),
+	}
+	// Multipart objects only carry an MD5 of their part MD5s, which cannot be
+	// compared with the data, so MD5 is left empty for them
+	info.MD5 = contentMD5(response.ContentMd5)
+	if response.ContentLength != nil {
+		info.Size = *response.ContentLength
+	}
+	if response.ETag != nil {
+		info.ETag = *response.ETag
+	}
+	if response.LastModified != nil {
+		info.LastModified = response.LastModified.Time
+	}
+
+	return info, nil
+}
+
+// contentMD5 returns the base64 MD5 of the object data, or an empty string if
+// md5 is missing or is the "<md5 of part MD5s>-<part count>" of a multipart
+// upload
+func contentMD5(md5 *string) string {
+	if md5 == nil || strings.Contains(*md5, "-") {
+		return ""
+	}
+	return *md5
+}
@@ -4,35 +4,70 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 
-	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
 	"github.com/oracle/oci-go-sdk/v65/objectstorage"
 )
 
+// objectStorageAPI is the part of the Object Storage SDK client that Client
+// uses, so tests can substitute a fake
+type objectStorageAPI interface {
+	Endpoint() string
+	PutObject(ctx context.Context, request objectstorage.PutObjectRequest) (objectstorage.PutObjectResponse, error)
+	GetObject(ctx context.Context, request objectstorage.GetObjectRequest) (objectstorage.GetObjectResponse, error)
+	HeadObject(ctx context.Context, request objectstorage.HeadObjectRequest) (objectstorage.HeadObjectResponse, error)
+	DeleteObject(ctx context.Context, request objectstorage.DeleteObjectRequest) (objectstorage.DeleteObjectResponse, error)
+	ListObjects(ctx context.Context, request objectstorage.ListObjectsRequest) (objectstorage.ListObjectsResponse, error)
+	CreateMultipartUpload(ctx context.Context, request objectstorage.CreateMultipartUploadRequest) (objectstorage.CreateMultipartUploadResponse, error)
+	UploadPart(ctx context.Context, request objectstorage.UploadPartRequest) (objectstorage.UploadPartResponse, error)
+	ListMultipartUploadParts(ctx context.Context, request objectstorage.ListMultipartUploadPartsRequest) (objectstorage.ListMultipartUploadPartsResponse, error)
+	CommitMultipartUpload(ctx context.Context, request objectstorage.CommitMultipartUploadRequest) (objectstorage.CommitMultipartUploadResponse, error)
+	AbortMultipartUpload(ctx context.Context, request objectstorage.AbortMultipartUploadRequest) (objectstorage.AbortMultipartUploadResponse, error)
+	UpdateObjectStorageTier(ctx context.Context, request objectstorage.UpdateObjectStorageTierRequest) (objectstorage.UpdateObjectStorageTierResponse, error)
+	RestoreObjects(ctx context.Context, request objectstorage.RestoreObjectsRequest) (objectstorage.RestoreObjectsResponse, error)
+	CreatePreauthenticatedRequest(ctx context.Context, request objectstorage.CreatePreauthenticatedRequestRequest) (objectstorage.CreatePreauthenticatedRequestResponse, error)
+	ListPreauthenticatedRequests(ctx context.Context, request objectstorage.ListPreauthenticatedRequestsRequest) (objectstorage.ListPreauthenticatedRequestsResponse, error)
+	DeletePreauthenticatedRequest(ctx context.Context, request objectstorage.DeletePreauthenticatedRequestRequest) (objectstorage.DeletePreauthenticatedRequestResponse, error)
+}
+
 // Client represents an Oracle Cloud Infrastructure client for Object Storage operations
 type Client struct {
-	objectStorageClient *objectstorage.ObjectStorageClient
+	objectStorageClient objectStorageAPI
 	namespace           string
 	bucketName          string
 	compartmentID       string
+	authMode            AuthMode
+	storageTier         objectstorage.StorageTierEnum
+	partSize            int64
+	parallelism         int
+	maxRetries          int
 }
 
 // Config holds the configuration for OCI client
 type Config struct {
+	AuthMode       AuthMode // Empty selects a mode automatically, see NewClient
 	ConfigFilePath string
 	Profile        string
 	Namespace      string
 	BucketName     string
 	CompartmentID  string
+	StorageTier    objectstorage.StorageTierEnum // Tier for new uploads, empty uses the bucket default
+
+	// Multipart upload settings, zero values use the defaults
+	PartSize    int64 // Bytes per part; files larger than this are uploaded in parts
+	Parallelism int   // Number of parts uploaded concurrently
+	MaxRetries  int   // Attempts per part before the upload is abandoned
 }
 
 // NewClient creates a new OCI Object Storage client
-// It reads credentials from ~/.oci/config or uses environment variables
+// Unless Config.AuthMode is set, credentials are chosen in this order:
+// OCI_CLI_AUTH, resource principal (OCI_RESOURCE_PRINCIPAL_VERSION set),
+// OCI_* environment variables, ~/.oci/config, and finally instance principal
+// when running on an OCI instance.
 func NewClient(config Config) (*Client, error) {
-	var configProvider common.ConfigurationProvider
-	var err error
-
-	// Try to load from config file first
+	// Resolve the default OCI config location
+	explicitConfigFile := config.ConfigFilePath != ""
 	if config.ConfigFilePath == "" {
 		// Use default OCI config location: ~/.oci/config
 		homeDir, err := os.UserHomeDir()
@@ -44,13 +79,9 @@ func NewClient(config Config) (*Client, error) {
 		config.Profile = "DEFAULT"
 	}
 
-	configProvider, err = common.ConfigurationProviderFromFileWithProfile(
-		config.ConfigFilePath,
-		config.Profile,
-		"",
-	)
+	configProvider, authMode, err := newConfigurationProvider(config, explicitConfigFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load OCI config: %w", err)
+		return nil, err
 	}
 
 	// Create Object Storage client
@@ -71,11 +102,26 @@ func NewClient(config Config) (*Client, error) {
 		namespace = *response.Value
 	}
 
+	if config.PartSize <= 0 {
+		config.PartSize = DefaultPartSize
+	}
+	if config.Parallelism <= 0 {
+		config.Parallelism = DefaultParallelism
+	}
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = DefaultMaxRetries
+	}
+
 	return &Client{
 		objectStorageClient: &client,
 		namespace:           namespace,
 		bucketName:          config.BucketName,
 		compartmentID:       config.CompartmentID,
+		authMode:            authMode,
+		storageTier:         config.StorageTier,
+		partSize:            config.PartSize,
+		parallelism:         config.Parallelism,
+		maxRetries:          config.MaxRetries,
 	}, nil
 }
 
@@ -84,7 +130,42 @@ func (c *Client) GetBucketName() string {
 	return c.bucketName
 }
 
+// GetAuthMode returns the authentication mode in use
+func (c *Client) GetAuthMode() AuthMode {
+	return c.authMode
+}
+
 // GetNamespace returns the OCI namespace
 func (c *Client) GetNamespace() string {
 	return c.namespace
 }
+
+// normalizeMetaKey strips the opc-meta- prefix and lowercases a metadata key
+func normalizeMetaKey(key string) string {
+	return strings.TrimPrefix(strings.ToLower(key), "opc-meta-")
+}
+
+// normalizeMeta returns a copy of the metadata returned by the SDK with
+// normalized keys
+func normalizeMeta(meta map[string]string) map[string]string {
+	if meta == nil {
+		return nil
+	}
+	normalized := make(map[string]string, len(meta))
+	for key, value := range meta {
+		normalized[normalizeMetaKey(key)] = value
+	}
+	return normalized
+}
+
+// objectMeta merges user metadata with the content checksum
+func objectMeta(metadata map[string]string, checksum string) map[string]string {
+	meta := make(map[string]string, len(metadata)+1)
+	for key, value := range metadata {
+		meta[normalizeMetaKey(key)] = value
+	}
+	if checksum != "" {
+		meta[storage.MetaSHA256] = checksum
+	}
+	return meta
+}
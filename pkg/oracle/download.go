@@ -2,67 +2,328 @@ package oracle
 
 import (
 	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"hash"
 	"io"
 	"os"
+	"sync"
 	"time"
 
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
 	"github.com/oracle/oci-go-sdk/v65/objectstorage"
 )
 
+const (
+	// partFileSuffix is appended to the local path while a download is in progress
+	partFileSuffix = ".part"
+	// downloadStateSuffix is appended to the part file to store resume state
+	downloadStateSuffix = ".json"
+)
+
 // DownloadResult contains information about the downloaded file
-type DownloadResult struct {
-	ObjectName   string
-	LocalPath    string
-	Size         int64
-	Duration     time.Duration
-	LastModified time.Time
+type DownloadResult = storage.DownloadResult
+
+// downloadState is persisted next to the partial file so an interrupted
+// download can be resumed
+type downloadState struct {
+	ETag      string       `json:"etag"`
+	Size      int64        `json:"size"`
+	ChunkSize int64        `json:"chunk_size"`
+	Chunks    map[int]bool `json:"chunks"` // completed chunk indexes
 }
 
-// DownloadFile downloads an object from Oracle Cloud Object Storage to a local file
+// DownloadFile downloads an object from Oracle Cloud Object Storage to a local file.
+// The object is fetched with parallel ranged requests into localPath + ".part";
+// if interrupted, calling DownloadFile again resumes the missing ranges. Before
+// the file is renamed to localPath it is verified against the SHA-256 recorded
+// on upload or the object's MD5, and ErrChecksumMismatch is returned on failure.
 func (c *Client) DownloadFile(ctx context.Context, objectName string, localPath string) (*DownloadResult, error) {
 	startTime := time.Now()
 
-	// Create the get object request
-	request := objectstorage.GetObjectRequest{
+	// Fetch size, ETag and checksums without reading the object
+	head, err := c.objectStorageClient.HeadObject(ctx, objectstorage.HeadObjectRequest{
 		NamespaceName: &c.namespace,
 		BucketName:    &c.bucketName,
 		ObjectName:    &objectName,
-	}
-
-	// Download the object
-	response, err := c.objectStorageClient.GetObject(ctx, request)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to download object %s: %w", objectName, err)
 	}
-	defer response.Content.Close()
 
-	// Create the local file
-	outFile, err := os.Create(localPath)
+	// Archived objects cannot be read until they are rehydrated
+	if head.ArchivalState == objectstorage.HeadObjectArchivalStateArchived ||
+		head.ArchivalState == objectstorage.HeadObjectArchivalStateRestoring {
+		return nil, fmt.Errorf("%w: %s is %s, rehydrate it first", storage.ErrObjectArchived, objectName, head.ArchivalState)
+	}
+
+	var size int64
+	if head.ContentLength != nil {
+		size = *head.ContentLength
+	}
+	var etag string
+	if head.ETag != nil {
+		etag = *head.ETag
+	}
+
+	partPath := localPath + partFileSuffix
+	statePath := partPath + downloadStateSuffix
+
+	state := loadDownloadState(statePath, etag, size)
+	if state == nil {
+		state = &downloadState{
+			ETag:      etag,
+			Size:      size,
+			ChunkSize: c.partSize,
+			Chunks:    make(map[int]bool),
+		}
+		// Discard any partial file from a different version of the object
+		os.Remove(partPath)
+	}
+
+	partFile, err := os.OpenFile(partPath, os.O_CREATE|os.O_RDWR, 0600)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create local file %s: %w", localPath, err)
+		return nil, fmt.Errorf("failed to create local file %s: %w", partPath, err)
+	}
+	defer partFile.Close()
+
+	if err := partFile.Truncate(size); err != nil {
+		return nil, fmt.Errorf("failed to allocate local file %s: %w", partPath, err)
+	}
+
+	if err := c.downloadChunks(ctx, objectName, partFile, state, statePath); err != nil {
+		return nil, fmt.Errorf("download interrupted (re-run to resume from %s): %w", partPath, err)
+	}
+
+	if err := partFile.Sync(); err != nil {
+		return nil, fmt.Errorf("failed to sync %s: %w", partPath, err)
 	}
-	defer outFile.Close()
 
-	// Copy the content to the local file
-	bytesWritten, err := io.Copy(outFile, response.Content)
+	verified, err := verifyDownload(partFile, size, head.OpcMeta, head.ContentMd5)
 	if err != nil {
-		return nil, fmt.Errorf("failed to write content to file: %w", err)
+		// A corrupt file must not be resumed from
+		partFile.Close()
+		os.Remove(partPath)
+		os.Remove(statePath)
+		return nil, fmt.Errorf("object %s: %w", objectName, err)
 	}
 
-	duration := time.Since(startTime)
+	if err := partFile.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close %s: %w", partPath, err)
+	}
+	if err := os.Rename(partPath, localPath); err != nil {
+		return nil, fmt.Errorf("failed to move download into place: %w", err)
+	}
+	os.Remove(statePath)
 
 	result := &DownloadResult{
-		ObjectName:   objectName,
-		LocalPath:    localPath,
-		Size:         bytesWritten,
-		Duration:     duration,
-		LastModified: response.LastModified.Time,
+		ObjectName: objectName,
+		LocalPath:  localPath,
+		Size:       size,
+		Duration:   time.Since(startTime),
+		Verified:   verified,
+	}
+	if head.LastModified != nil {
+		result.LastModified = head.LastModified.Time
 	}
 
 	return result, nil
 }
 
+// downloadChunks fetches every chunk not yet recorded in state using
+// parallel ranged GET requests
+func (c *Client) downloadChunks(ctx context.Context, objectName string, file *os.File, state *downloadState, statePath string) error {
+	numChunks := int((state.Size + state.ChunkSize - 1) / state.ChunkSize)
+
+	pending := make(chan int, numChunks)
+	for i := 0; i < numChunks; i++ {
+		if !state.Chunks[i] {
+			pending <- i
+		}
+	}
+	close(pending)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+
+	for i := 0; i < c.parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range pending {
+				offset := int64(chunk) * state.ChunkSize
+				length := state.ChunkSize
+				if offset+length > state.Size {
+					length = state.Size - offset
+				}
+
+				err := c.downloadRangeWithRetry(ctx, objectName, state.ETag, file, offset, length)
+
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+				} else {
+					state.Chunks[chunk] = true
+					if saveErr := saveDownloadState(statePath, state); saveErr != nil && firstErr == nil {
+						firstErr = saveErr
+						cancel()
+					}
+				}
+				mu.Unlock()
+
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
+// downloadRangeWithRetry fetches one byte range into file at offset,
+// retrying with exponential backoff. If-Match ensures every range comes
+// from the same version of the object.
+func (c *Client) downloadRangeWithRetry(ctx context.Context, objectName, etag string, file *os.File, offset, length int64) error {
+	byteRange := fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+
+	var lastErr error
+	for attempt := 0; attempt < c.maxRetries; attempt++ {
+		if attempt > 0 {
+			backoff := retryBaseDelay << uint(attempt-1)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+		}
+
+		request := objectstorage.GetObjectRequest{
+			NamespaceName: &c.namespace,
+			BucketName:    &c.bucketName,
+			ObjectName:    &objectName,
+			Range:         &byteRange,
+		}
+		if etag != "" {
+			request.IfMatch = &etag
+		}
+
+		response, err := c.objectStorageClient.GetObject(ctx, request)
+		if err != nil {
+			lastErr = err
+		} else {
+			written, err := io.Copy(io.NewOffsetWriter(file, offset), io.LimitReader(response.Content, length))
+			response.Content.Close()
+			if err == nil && written == length {
+				return nil
+			}
+			if err == nil {
+				err = fmt.Errorf("short read: got %d of %d bytes", written, length)
+			}
+			lastErr = err
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	return fmt.Errorf("failed to download range %s after %d attempts: %w", byteRange, c.maxRetries, lastErr)
+}
+
+// verifyDownload checks the file against the stored SHA-256 metadata or, for
+// single-part uploads, the object's MD5. It returns the checksum used, or an
+// empty string if the object carries no usable checksum.
+func verifyDownload(file *os.File, size int64, meta map[string]string, contentMD5 *string) (string, error) {
+	var (
+		algorithm string
+		expected  string
+		h         hash.Hash
+		encode    func([]byte) string
+	)
+
+	if sum := lookupMeta(meta, storage.MetaSHA256); sum != "" {
+		algorithm, expected, h, encode = "sha256", sum, sha256.New(), hex.EncodeToString
+	} else if contentMD5 != nil && *contentMD5 != "" {
+		algorithm, expected, h, encode = "md5", *contentMD5, md5.New(), base64.StdEncoding.EncodeToString
+	} else {
+		return "", nil
+	}
+
+	if _, err := io.Copy(h, io.NewSectionReader(file, 0, size)); err != nil {
+		return "", fmt.Errorf("failed to checksum download: %w", err)
+	}
+
+	if actual := encode(h.Sum(nil)); actual != expected {
+		return "", fmt.Errorf("%w: %s expected %s, got %s", storage.ErrChecksumMismatch, algorithm, expected, actual)
+	}
+
+	return algorithm, nil
+}
+
+// lookupMeta reads a user metadata value regardless of how the SDK cased or
+// prefixed the header name
+func lookupMeta(meta map[string]string, key string) string {
+	for k, v := range meta {
+		if normalizeMetaKey(k) == key {
+			return v
+		}
+	}
+	return ""
+}
+
+// loadDownloadState returns saved progress for a download, or nil if there is
+// none or the object changed since it was written
+func loadDownloadState(statePath string, etag string, size int64) *downloadState {
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		return nil
+	}
+
+	var state downloadState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil
+	}
+
+	if state.ETag != etag || state.Size != size || state.ChunkSize <= 0 || state.Chunks == nil {
+		return nil
+	}
+
+	return &state
+}
+
+// saveDownloadState atomically writes the resume state file
+func saveDownloadState(statePath string, state *downloadState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode download state: %w", err)
+	}
+
+	tmpPath := statePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write download state: %w", err)
+	}
+	if err := os.Rename(tmpPath, statePath); err != nil {
+		return fmt.Errorf("failed to write download state: %w", err)
+	}
+
+	return nil
+}
+
 // DownloadBackup is a convenience function that downloads a backup file
 // It downloads from the specified object name to the local path
 func (c *Client) DownloadBackup(ctx context.Context, objectName string, localPath string) (*DownloadResult, error) {
@@ -0,0 +1,200 @@
+package oracle
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
+)
+
+func assertFileContent(t *testing.T, path string, want []byte) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("%s = %q, want %q", path, data, want)
+	}
+}
+
+func TestDownloadFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		meta     map[string]string
+		verified string
+	}{
+		{name: "sha256 metadata", meta: map[string]string{"opc-meta-sha256": sha256Hex(testData)}, verified: "sha256"},
+		{name: "content md5", verified: "md5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeObjectStorage()
+			client := newFakeClient(t, fake)
+			fake.putObject("backups/db.tar.gz", testData, tt.meta)
+			localPath := filepath.Join(t.TempDir(), "db.tar.gz")
+
+			result, err := client.DownloadFile(context.Background(), "backups/db.tar.gz", localPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertFileContent(t, localPath, testData)
+			if result.Verified != tt.verified || result.Size != int64(len(testData)) {
+				t.Errorf("result verified %q size %d, want %q %d", result.Verified, result.Size, tt.verified, len(testData))
+			}
+			if fake.getCalls != 4 {
+				t.Errorf("fetched %d ranges, want 4", fake.getCalls)
+			}
+			assertNoFile(t, localPath+partFileSuffix)
+			assertNoFile(t, localPath+partFileSuffix+downloadStateSuffix)
+		})
+	}
+}
+
+// interruptDownload runs a download that fails at the third chunk and leaves
+// the partial file and state behind
+func interruptDownload(t *testing.T, client *Client, fake *fakeObjectStorage, localPath string) {
+	t.Helper()
+	fake.failRange = func(offset int64) bool { return offset >= 2*client.partSize }
+	if _, err := client.DownloadFile(context.Background(), "backups/db.tar.gz", localPath); err == nil {
+		t.Fatal("download with a failing range succeeded")
+	}
+	fake.failRange = nil
+	fake.getCalls = 0
+
+	assertNoFile(t, localPath)
+	if _, err := os.Stat(localPath + partFileSuffix + downloadStateSuffix); err != nil {
+		t.Fatalf("state file not kept for resume: %v", err)
+	}
+}
+
+func TestDownloadFileResumes(t *testing.T) {
+	fake := newFakeObjectStorage()
+	client := newFakeClient(t, fake)
+	fake.putObject("backups/db.tar.gz", testData, map[string]string{"sha256": sha256Hex(testData)})
+	localPath := filepath.Join(t.TempDir(), "db.tar.gz")
+
+	interruptDownload(t, client, fake, localPath)
+
+	result, err := client.DownloadFile(context.Background(), "backups/db.tar.gz", localPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertFileContent(t, localPath, testData)
+	if result.Verified != "sha256" {
+		t.Errorf("verified = %q, want sha256", result.Verified)
+	}
+	if fake.getCalls != 2 {
+		t.Errorf("fetched %d ranges on resume, want the 2 missing ones", fake.getCalls)
+	}
+	assertNoFile(t, localPath+partFileSuffix+downloadStateSuffix)
+}
+
+func TestDownloadFileRestartsWhenETagChanged(t *testing.T) {
+	fake := newFakeObjectStorage()
+	client := newFakeClient(t, fake)
+	fake.putObject("backups/db.tar.gz", testData, map[string]string{"sha256": sha256Hex(testData)})
+	localPath := filepath.Join(t.TempDir(), "db.tar.gz")
+
+	interruptDownload(t, client, fake, localPath)
+
+	// The object is replaced by one of the same size: nothing may be reused
+	replaced := bytes.ToUpper(testData)
+	fake.putObject("backups/db.tar.gz", replaced, map[string]string{"sha256": sha256Hex(replaced)})
+
+	if _, err := client.DownloadFile(context.Background(), "backups/db.tar.gz", localPath); err != nil {
+		t.Fatal(err)
+	}
+
+	assertFileContent(t, localPath, replaced)
+	if fake.getCalls != 4 {
+		t.Errorf("fetched %d ranges, want all 4 of the new object", fake.getCalls)
+	}
+}
+
+func TestDownloadRangeRequiresSameETag(t *testing.T) {
+	fake := newFakeObjectStorage()
+	client := newFakeClient(t, fake)
+	fake.putObject("backups/db.tar.gz", testData, nil)
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "db.tar.gz.part"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	err = client.downloadRangeWithRetry(context.Background(), "backups/db.tar.gz", "stale-etag", file, 0, client.partSize)
+	if err == nil {
+		t.Fatal("range of a replaced object was accepted")
+	}
+}
+
+func TestDownloadFileRetriesShortRange(t *testing.T) {
+	fake := newFakeObjectStorage()
+	client := newFakeClient(t, fake)
+	fake.putObject("backups/db.tar.gz", testData, map[string]string{"sha256": sha256Hex(testData)})
+	localPath := filepath.Join(t.TempDir(), "db.tar.gz")
+
+	short := true
+	fake.shortRange = func(offset int64) bool {
+		if offset == client.partSize && short {
+			short = false
+			return true
+		}
+		return false
+	}
+
+	if _, err := client.DownloadFile(context.Background(), "backups/db.tar.gz", localPath); err != nil {
+		t.Fatal(err)
+	}
+
+	assertFileContent(t, localPath, testData)
+	if fake.getCalls != 5 {
+		t.Errorf("fetched %d ranges, want 5 with one retry", fake.getCalls)
+	}
+}
+
+func TestDownloadFileChecksumMismatch(t *testing.T) {
+	corrupt := bytes.Repeat([]byte("x"), len(testData))
+	wrongMD5 := md5.Sum(corrupt)
+
+	tests := []struct {
+		name string
+		meta map[string]string
+		md5  string
+	}{
+		{name: "sha256", meta: map[string]string{"sha256": sha256Hex(corrupt)}},
+		{name: "md5", md5: base64.StdEncoding.EncodeToString(wrongMD5[:])},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeObjectStorage()
+			client := newFakeClient(t, fake)
+			fake.putObject("backups/db.tar.gz", testData, tt.meta)
+			if tt.md5 != "" {
+				fake.object("backups/db.tar.gz").md5 = tt.md5
+			}
+			localPath := filepath.Join(t.TempDir(), "db.tar.gz")
+
+			_, err := client.DownloadFile(context.Background(), "backups/db.tar.gz", localPath)
+			if !errors.Is(err, storage.ErrChecksumMismatch) {
+				t.Fatalf("err = %v, want ErrChecksumMismatch", err)
+			}
+
+			// Neither the corrupt file nor its resume state may survive
+			assertNoFile(t, localPath)
+			assertNoFile(t, localPath+partFileSuffix)
+			assertNoFile(t, localPath+partFileSuffix+downloadStateSuffix)
+		})
+	}
+}
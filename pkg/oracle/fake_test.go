@@ -0,0 +1,380 @@
+package oracle
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+)
+
+// fakeObject is an object stored by fakeObjectStorage
+type fakeObject struct {
+	data []byte
+	etag string
+	meta map[string]string
+	md5  string // base64 Content-MD5, empty for multipart objects
+
+	multipartMD5 string // MD5 of the part MD5s and the part count, multipart objects only
+
+	archivalState objectstorage.HeadObjectArchivalStateEnum
+}
+
+// fakeUpload is an uncommitted multipart upload
+type fakeUpload struct {
+	object string
+	meta   map[string]string
+	parts  map[int][]byte
+	etags  map[int]string
+}
+
+// fakeServiceError is an Object Storage error with an HTTP status, as the SDK
+// returns it
+type fakeServiceError struct {
+	status int
+	code   string
+}
+
+func (e fakeServiceError) Error() string           { return fmt.Sprintf("%d %s", e.status, e.code) }
+func (e fakeServiceError) GetHTTPStatusCode() int  { return e.status }
+func (e fakeServiceError) GetMessage() string      { return e.code }
+func (e fakeServiceError) GetCode() string         { return e.code }
+func (e fakeServiceError) GetOpcRequestID() string { return "" }
+
+func notFound(code string) error {
+	return fakeServiceError{status: http.StatusNotFound, code: code}
+}
+
+// fakeObjectStorage is an in-memory Object Storage implementing the calls
+// used by uploads, downloads and shared links. Calls it does not implement panic through
+// the nil embedded interface.
+type fakeObjectStorage struct {
+	objectStorageAPI
+
+	mu      sync.Mutex
+	objects map[string]*fakeObject
+	uploads map[string]*fakeUpload
+	nextID  int
+
+	created   int         // multipart uploads created
+	aborted   []string    // upload IDs of aborted multipart uploads
+	partCalls map[int]int // UploadPart calls per part number
+	getCalls  int         // ranged GetObject calls
+	listPage  int         // parts per ListMultipartUploadParts page
+
+	// pars records the shared links created
+	pars []objectstorage.CreatePreauthenticatedRequestDetails
+
+	// failAbort fails every AbortMultipartUpload call
+	failAbort bool
+	// failPart fails an UploadPart call; call counts from 1 per part
+	failPart func(partNum, call int) bool
+	// corruptPart flips a byte of a part in transit, so its MD5 no longer matches
+	corruptPart func(partNum, call int) bool
+	// failRange fails a ranged GetObject starting at offset
+	failRange func(offset int64) bool
+	// shortRange serves only half of a ranged GetObject starting at offset
+	shortRange func(offset int64) bool
+}
+
+func newFakeObjectStorage() *fakeObjectStorage {
+	return &fakeObjectStorage{
+		objects:   make(map[string]*fakeObject),
+		uploads:   make(map[string]*fakeUpload),
+		partCalls: make(map[int]int),
+		listPage:  2,
+	}
+}
+
+// newFakeClient returns a Client using fake with small parts and no backoff
+func newFakeClient(t *testing.T, fake *fakeObjectStorage) *Client {
+	t.Helper()
+
+	delay := retryBaseDelay
+	retryBaseDelay = time.Millisecond
+	t.Cleanup(func() { retryBaseDelay = delay })
+
+	return &Client{
+		objectStorageClient: fake,
+		namespace:           "namespace",
+		bucketName:          "backups",
+		partSize:            8,
+		parallelism:         1,
+		maxRetries:          3,
+	}
+}
+
+func (f *fakeObjectStorage) newETag() string {
+	f.nextID++
+	return fmt.Sprintf("etag-%d", f.nextID)
+}
+
+// putObject stores an object as a single-part upload would
+func (f *fakeObjectStorage) putObject(name string, data []byte, meta map[string]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sum := md5.Sum(data)
+	f.objects[name] = &fakeObject{
+		data: append([]byte(nil), data...),
+		etag: f.newETag(),
+		meta: meta,
+		md5:  base64.StdEncoding.EncodeToString(sum[:]),
+	}
+}
+
+// object returns the stored content of name, or nil if it does not exist
+func (f *fakeObjectStorage) object(name string) *fakeObject {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.objects[name]
+}
+
+func (f *fakeObjectStorage) HeadObject(ctx context.Context, request objectstorage.HeadObjectRequest) (objectstorage.HeadObjectResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	obj, ok := f.objects[*request.ObjectName]
+	if !ok {
+		return objectstorage.HeadObjectResponse{}, notFound("ObjectNotFound")
+	}
+
+	size := int64(len(obj.data))
+	response := objectstorage.HeadObjectResponse{
+		ETag:          &obj.etag,
+		ContentLength: &size,
+		OpcMeta:       obj.meta,
+		ArchivalState: obj.archivalState,
+	}
+	if obj.md5 != "" {
+		response.ContentMd5 = &obj.md5
+	}
+	if obj.multipartMD5 != "" {
+		response.OpcMultipartMd5 = &obj.multipartMD5
+	}
+	return response, nil
+}
+
+func (f *fakeObjectStorage) GetObject(ctx context.Context, request objectstorage.GetObjectRequest) (objectstorage.GetObjectResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	obj, ok := f.objects[*request.ObjectName]
+	if !ok {
+		return objectstorage.GetObjectResponse{}, notFound("ObjectNotFound")
+	}
+	if request.IfMatch != nil && *request.IfMatch != obj.etag {
+		return objectstorage.GetObjectResponse{}, errors.New("412 IfMatchFailed")
+	}
+
+	data := obj.data
+	if request.Range != nil {
+		var start, end int64
+		if _, err := fmt.Sscanf(*request.Range, "bytes=%d-%d", &start, &end); err != nil {
+			return objectstorage.GetObjectResponse{}, fmt.Errorf("400 invalid range %q", *request.Range)
+		}
+		f.getCalls++
+		if f.failRange != nil && f.failRange(start) {
+			return objectstorage.GetObjectResponse{}, errors.New("503 ServiceUnavailable")
+		}
+		if end >= int64(len(data)) {
+			end = int64(len(data)) - 1
+		}
+		data = data[start : end+1]
+		if f.shortRange != nil && f.shortRange(start) {
+			data = data[:len(data)/2]
+		}
+	}
+
+	size := int64(len(data))
+	return objectstorage.GetObjectResponse{
+		Content:       io.NopCloser(bytes.NewReader(data)),
+		ContentLength: &size,
+		ETag:          &obj.etag,
+	}, nil
+}
+
+func (f *fakeObjectStorage) CreateMultipartUpload(ctx context.Context, request objectstorage.CreateMultipartUploadRequest) (objectstorage.CreateMultipartUploadResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.created++
+	f.nextID++
+	uploadID := fmt.Sprintf("upload-%d", f.nextID)
+	f.uploads[uploadID] = &fakeUpload{
+		object: *request.Object,
+		meta:   request.Metadata,
+		parts:  make(map[int][]byte),
+		etags:  make(map[int]string),
+	}
+
+	return objectstorage.CreateMultipartUploadResponse{
+		MultipartUpload: objectstorage.MultipartUpload{
+			UploadId: &uploadID,
+			Object:   request.Object,
+		},
+	}, nil
+}
+
+func (f *fakeObjectStorage) UploadPart(ctx context.Context, request objectstorage.UploadPartRequest) (objectstorage.UploadPartResponse, error) {
+	body, err := io.ReadAll(request.UploadPartBody)
+	if err != nil {
+		return objectstorage.UploadPartResponse{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	partNum := *request.UploadPartNum
+	f.partCalls[partNum]++
+	call := f.partCalls[partNum]
+
+	upload, ok := f.uploads[*request.UploadId]
+	if !ok {
+		return objectstorage.UploadPartResponse{}, notFound("NoSuchUpload")
+	}
+	if f.failPart != nil && f.failPart(partNum, call) {
+		return objectstorage.UploadPartResponse{}, errors.New("503 ServiceUnavailable")
+	}
+	if f.corruptPart != nil && f.corruptPart(partNum, call) {
+		body[0] ^= 0xff
+	}
+	if request.ContentMD5 == nil {
+		return objectstorage.UploadPartResponse{}, errors.New("400 missing Content-MD5")
+	}
+	sum := md5.Sum(body)
+	if base64.StdEncoding.EncodeToString(sum[:]) != *request.ContentMD5 {
+		return objectstorage.UploadPartResponse{}, errors.New("400 InvalidContentMD5")
+	}
+
+	etag := f.newETag()
+	upload.parts[partNum] = body
+	upload.etags[partNum] = etag
+	return objectstorage.UploadPartResponse{ETag: &etag}, nil
+}
+
+func (f *fakeObjectStorage) ListMultipartUploadParts(ctx context.Context, request objectstorage.ListMultipartUploadPartsRequest) (objectstorage.ListMultipartUploadPartsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	upload, ok := f.uploads[*request.UploadId]
+	if !ok {
+		return objectstorage.ListMultipartUploadPartsResponse{}, notFound("NoSuchUpload")
+	}
+
+	var numbers []int
+	for partNum := range upload.parts {
+		numbers = append(numbers, partNum)
+	}
+	sort.Ints(numbers)
+
+	start := 0
+	if request.Page != nil {
+		start, _ = strconv.Atoi(*request.Page)
+	}
+	end := min(start+f.listPage, len(numbers))
+
+	var response objectstorage.ListMultipartUploadPartsResponse
+	for _, partNum := range numbers[start:end] {
+		num, etag := partNum, upload.etags[partNum]
+		response.Items = append(response.Items, objectstorage.MultipartUploadPartSummary{
+			PartNumber: &num,
+			Etag:       &etag,
+		})
+	}
+	if end < len(numbers) {
+		next := strconv.Itoa(end)
+		response.OpcNextPage = &next
+	}
+	return response, nil
+}
+
+func (f *fakeObjectStorage) CommitMultipartUpload(ctx context.Context, request objectstorage.CommitMultipartUploadRequest) (objectstorage.CommitMultipartUploadResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	upload, ok := f.uploads[*request.UploadId]
+	if !ok {
+		return objectstorage.CommitMultipartUploadResponse{}, notFound("NoSuchUpload")
+	}
+
+	var data []byte
+	partMD5s := md5.New()
+	for i, part := range request.CommitMultipartUploadDetails.PartsToCommit {
+		if *part.PartNum != i+1 {
+			return objectstorage.CommitMultipartUploadResponse{}, fmt.Errorf("400 part %d committed out of order", *part.PartNum)
+		}
+		if upload.etags[*part.PartNum] != *part.Etag {
+			return objectstorage.CommitMultipartUploadResponse{}, fmt.Errorf("400 part %d etag mismatch", *part.PartNum)
+		}
+		data = append(data, upload.parts[*part.PartNum]...)
+		sum := md5.Sum(upload.parts[*part.PartNum])
+		partMD5s.Write(sum[:])
+	}
+
+	meta := make(map[string]string, len(upload.meta))
+	for key, value := range upload.meta {
+		meta[strings.TrimPrefix(key, "opc-meta-")] = value
+	}
+
+	obj := &fakeObject{
+		data:         data,
+		etag:         f.newETag(),
+		meta:         meta,
+		multipartMD5: fmt.Sprintf("%s-%d", base64.StdEncoding.EncodeToString(partMD5s.Sum(nil)), len(request.CommitMultipartUploadDetails.PartsToCommit)),
+	}
+	f.objects[upload.object] = obj
+	delete(f.uploads, *request.UploadId)
+
+	return objectstorage.CommitMultipartUploadResponse{ETag: &obj.etag}, nil
+}
+
+func (f *fakeObjectStorage) AbortMultipartUpload(ctx context.Context, request objectstorage.AbortMultipartUploadRequest) (objectstorage.AbortMultipartUploadResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failAbort {
+		return objectstorage.AbortMultipartUploadResponse{}, errors.New("503 ServiceUnavailable")
+	}
+	if _, ok := f.uploads[*request.UploadId]; !ok {
+		return objectstorage.AbortMultipartUploadResponse{}, notFound("NoSuchUpload")
+	}
+	delete(f.uploads, *request.UploadId)
+	f.aborted = append(f.aborted, *request.UploadId)
+	return objectstorage.AbortMultipartUploadResponse{}, nil
+}
+
+func (f *fakeObjectStorage) Endpoint() string {
+	return "https://objectstorage.example.com"
+}
+
+func (f *fakeObjectStorage) CreatePreauthenticatedRequest(ctx context.Context, request objectstorage.CreatePreauthenticatedRequestRequest) (objectstorage.CreatePreauthenticatedRequestResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	details := request.CreatePreauthenticatedRequestDetails
+	f.pars = append(f.pars, details)
+
+	id := fmt.Sprintf("par-%d", len(f.pars))
+	accessURI := "/p/secret/n/" + *request.NamespaceName + "/b/" + *request.BucketName + "/o/" + *details.ObjectName
+	return objectstorage.CreatePreauthenticatedRequestResponse{
+		PreauthenticatedRequest: objectstorage.PreauthenticatedRequest{
+			Id:          &id,
+			Name:        details.Name,
+			ObjectName:  details.ObjectName,
+			AccessUri:   &accessURI,
+			TimeExpires: details.TimeExpires,
+		},
+	}, nil
+}
@@ -3,62 +3,135 @@ package oracle
 import (
 	"context"
 	"fmt"
-	"time"
+	"strings"
 
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
 	"github.com/oracle/oci-go-sdk/v65/objectstorage"
 )
 
 // ObjectInfo contains information about an object in Object Storage
-type ObjectInfo struct {
-	Name         string
-	Size         int64
-	LastModified time.Time
-	ETag         string
-}
+type ObjectInfo = storage.ObjectInfo
+
+// ListOptions controls a paginated listing, see storage.ListOptions
+type ListOptions = storage.ListOptions
+
+// defaultListFields are always requested; OCI only returns the name otherwise
+var defaultListFields = []string{"name", "size", "etag", "timeModified"}
 
 // ListObjects lists all objects in the bucket with an optional prefix
 func (c *Client) ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	return c.ListObjectsWithOptions(ctx, ListOptions{Prefix: prefix})
+}
+
+// ListObjectsWithOptions lists all objects matching opts, following every page
+func (c *Client) ListObjectsWithOptions(ctx context.Context, opts ListOptions) ([]ObjectInfo, error) {
 	var objects []ObjectInfo
 
+	err := c.Walk(ctx, opts, func(obj ObjectInfo) error {
+		objects = append(objects, obj)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// Walk calls fn for every object matching opts, fetching one page at a time
+// so that buckets with years of backups can be processed without holding
+// the whole listing in memory
+func (c *Client) Walk(ctx context.Context, opts ListOptions, fn storage.WalkFunc) error {
+	fields := append([]string{}, defaultListFields...)
+	for _, field := range opts.Fields {
+		// Metadata is not part of the list API and is fetched per page below
+		if field != storage.FieldMetadata {
+			fields = append(fields, field)
+		}
+	}
+	fieldList := strings.Join(fields, ",")
+
 	request := objectstorage.ListObjectsRequest{
 		NamespaceName: &c.namespace,
 		BucketName:    &c.bucketName,
-		Prefix:        &prefix,
+		Prefix:        &opts.Prefix,
+		Fields:        &fieldList,
 	}
-
-	response, err := c.objectStorageClient.ListObjects(ctx, request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list objects: %w", err)
+	if opts.Start != "" {
+		request.Start = &opts.Start
+	}
+	if opts.End != "" {
+		request.End = &opts.End
+	}
+	if opts.PageSize > 0 {
+		request.Limit = &opts.PageSize
 	}
 
-	for _, obj := range response.Objects {
-		if obj.Name != nil {
-			info := ObjectInfo{
-				Name: *obj.Name,
-			}
-			if obj.Size != nil {
-				info.Size = *obj.Size
+	for {
+		response, err := c.objectStorageClient.ListObjects(ctx, request)
+		if err != nil {
+			return fmt.Errorf("failed to list objects: %w", err)
+		}
+
+		page := make([]ObjectInfo, 0, len(response.Objects))
+		for _, obj := range response.Objects {
+			if obj.Name != nil {
+				page = append(page, objectInfoFromSummary(obj))
 			}
-			if obj.TimeModified != nil {
-				info.LastModified = obj.TimeModified.Time
+		}
+
+		// Metadata is not part of the list API: HEAD the page's objects
+		if opts.HasField(storage.FieldMetadata) {
+			if err := storage.FetchMetadata(ctx, page, c.Stat); err != nil {
+				return err
 			}
-			if obj.Etag != nil {
-				info.ETag = *obj.Etag
+		}
+
+		for _, info := range page {
+			if err := fn(info); err != nil {
+				return err
 			}
-			objects = append(objects, info)
 		}
+
+		// NextStartWith is set whenever more objects remain
+		if response.NextStartWith == nil || *response.NextStartWith == "" {
+			break
+		}
+		request.Start = response.NextStartWith
 	}
 
-	return objects, nil
+	return nil
+}
+
+// objectInfoFromSummary converts an OCI object summary to ObjectInfo
+func objectInfoFromSummary(obj objectstorage.ObjectSummary) ObjectInfo {
+	info := ObjectInfo{
+		Name:          *obj.Name,
+		StorageTier:   string(obj.StorageTier),
+		ArchivalState: string(obj.ArchivalState),
+	}
+	if obj.Size != nil {
+		info.Size = *obj.Size
+	}
+	if obj.TimeModified != nil {
+		info.LastModified = obj.TimeModified.Time
+	}
+	if obj.Etag != nil {
+		info.ETag = *obj.Etag
+	}
+	info.MD5 = contentMD5(obj.Md5)
+	if obj.TimeCreated != nil {
+		info.TimeCreated = obj.TimeCreated.Time
+	}
+	return info
 }
 
 // ListBackups lists all backup files (objects with 'backups/' prefix)
 func (c *Client) ListBackups(ctx context.Context) ([]ObjectInfo, error) {
-	return c.ListObjects(ctx, "backups/")
+	return c.ListObjects(ctx, storage.BackupsPrefix)
 }
 
 // ListBackupsByDate lists backups for a specific year and month
 func (c *Client) ListBackupsByDate(ctx context.Context, year int, month int) ([]ObjectInfo, error) {
-	prefix := fmt.Sprintf("backups/%d/%02d/", year, month)
-	return c.ListObjects(ctx, prefix)
+	return c.ListObjects(ctx, storage.BackupDatePrefix(year, month))
 }
@@ -0,0 +1,402 @@
+package oracle
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+)
+
+const (
+	// DefaultPartSize is the multipart part size used when none is configured
+	DefaultPartSize int64 = 128 * 1024 * 1024
+	// DefaultParallelism is the number of parts uploaded concurrently by default
+	DefaultParallelism = 4
+	// DefaultMaxRetries is the number of attempts made for each part by default
+	DefaultMaxRetries = 5
+
+	// maxParts is the OCI limit on parts in a single multipart upload
+	maxParts = 10000
+	// stateFileSuffix is appended to the local path to store resume state
+	stateFileSuffix = ".upload-state.json"
+)
+
+// retryBaseDelay is the backoff before the first retry of a part or range;
+// it doubles with every further attempt
+var retryBaseDelay = time.Second
+
+// uploadState is persisted next to the local file so an interrupted
+// multipart upload can be resumed instead of starting from zero
+type uploadState struct {
+	ObjectName string         `json:"object_name"`
+	UploadID   string         `json:"upload_id"`
+	FileSize   int64          `json:"file_size"`
+	ModTime    time.Time      `json:"mod_time"`
+	PartSize   int64          `json:"part_size"`
+	Parts      map[int]string `json:"parts"` // part number -> ETag
+}
+
+// StateFilePath returns the path of the resume state file for a local file
+func StateFilePath(localPath string) string {
+	return localPath + stateFileSuffix
+}
+
+// multipartMeta adds the opc-meta- prefix the multipart API expects on keys
+func multipartMeta(meta map[string]string) map[string]string {
+	prefixed := make(map[string]string, len(meta))
+	for key, value := range meta {
+		prefixed["opc-meta-"+key] = value
+	}
+	return prefixed
+}
+
+// uploadMultipart uploads a file in parts, in parallel, retrying failed parts.
+// Progress is recorded in a state file so a later call with the same file and
+// object name resumes where the previous one stopped.
+func (c *Client) uploadMultipart(ctx context.Context, file *os.File, fileInfo os.FileInfo, objectName string, metadata map[string]string) (string, error) {
+	statePath := StateFilePath(file.Name())
+	state, err := c.loadUploadState(ctx, statePath, fileInfo, objectName)
+	if err != nil {
+		return "", err
+	}
+
+	if state == nil {
+		partSize := c.partSize
+		// Grow the part size so the file fits in the maximum number of parts
+		if fileInfo.Size()/partSize >= maxParts {
+			partSize = fileInfo.Size()/maxParts + 1
+		}
+
+		// Record a SHA-256 so downloads can be verified end to end
+		checksum, err := fileSHA256(file, fileInfo.Size())
+		if err != nil {
+			return "", err
+		}
+
+		request := objectstorage.CreateMultipartUploadRequest{
+			NamespaceName: &c.namespace,
+			BucketName:    &c.bucketName,
+			CreateMultipartUploadDetails: objectstorage.CreateMultipartUploadDetails{
+				Object:      &objectName,
+				Metadata:    multipartMeta(objectMeta(metadata, checksum)),
+				StorageTier: c.storageTier,
+			},
+		}
+
+		response, err := c.objectStorageClient.CreateMultipartUpload(ctx, request)
+		if err != nil {
+			return "", fmt.Errorf("failed to create multipart upload: %w", err)
+		}
+
+		state = &uploadState{
+			ObjectName: objectName,
+			UploadID:   *response.UploadId,
+			FileSize:   fileInfo.Size(),
+			ModTime:    fileInfo.ModTime(),
+			PartSize:   partSize,
+			Parts:      make(map[int]string),
+		}
+		if err := saveUploadState(statePath, state); err != nil {
+			return "", err
+		}
+	}
+
+	numParts := int((state.FileSize + state.PartSize - 1) / state.PartSize)
+
+	// Queue the parts that are still missing
+	pending := make(chan int, numParts)
+	for partNum := 1; partNum <= numParts; partNum++ {
+		if _, done := state.Parts[partNum]; !done {
+			pending <- partNum
+		}
+	}
+	close(pending)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+
+	for i := 0; i < c.parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for partNum := range pending {
+				offset := int64(partNum-1) * state.PartSize
+				size := state.PartSize
+				if offset+size > state.FileSize {
+					size = state.FileSize - offset
+				}
+
+				etag, err := c.uploadPartWithRetry(ctx, file, state, partNum, offset, size)
+
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+				} else {
+					state.Parts[partNum] = etag
+					if saveErr := saveUploadState(statePath, state); saveErr != nil && firstErr == nil {
+						firstErr = saveErr
+						cancel()
+					}
+				}
+				mu.Unlock()
+
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return "", fmt.Errorf("multipart upload interrupted (re-run to resume from %s): %w", statePath, firstErr)
+	}
+
+	// Commit the parts in order
+	parts := make([]objectstorage.CommitMultipartUploadPartDetails, 0, len(state.Parts))
+	for partNum, etag := range state.Parts {
+		num, tag := partNum, etag
+		parts = append(parts, objectstorage.CommitMultipartUploadPartDetails{
+			PartNum: &num,
+			Etag:    &tag,
+		})
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return *parts[i].PartNum < *parts[j].PartNum
+	})
+
+	request := objectstorage.CommitMultipartUploadRequest{
+		NamespaceName: &c.namespace,
+		BucketName:    &c.bucketName,
+		ObjectName:    &objectName,
+		UploadId:      &state.UploadID,
+		CommitMultipartUploadDetails: objectstorage.CommitMultipartUploadDetails{
+			PartsToCommit: parts,
+		},
+	}
+
+	response, err := c.objectStorageClient.CommitMultipartUpload(ctx, request)
+	if err != nil {
+		return "", fmt.Errorf("failed to commit multipart upload (re-run to resume from %s): %w", statePath, err)
+	}
+
+	os.Remove(statePath)
+
+	if response.ETag == nil {
+		return "", nil
+	}
+	return *response.ETag, nil
+}
+
+// uploadPartWithRetry uploads a single part, retrying with exponential backoff
+func (c *Client) uploadPartWithRetry(ctx context.Context, file *os.File, state *uploadState, partNum int, offset, size int64) (string, error) {
+	section := io.NewSectionReader(file, offset, size)
+
+	// The MD5 lets Object Storage reject parts corrupted in transit
+	hash := md5.New()
+	if _, err := io.Copy(hash, section); err != nil {
+		return "", fmt.Errorf("failed to read part %d: %w", partNum, err)
+	}
+	contentMD5 := base64.StdEncoding.EncodeToString(hash.Sum(nil))
+
+	var lastErr error
+	for attempt := 0; attempt < c.maxRetries; attempt++ {
+		if attempt > 0 {
+			backoff := retryBaseDelay << uint(attempt-1)
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-time.After(backoff):
+			}
+		}
+
+		request := objectstorage.UploadPartRequest{
+			NamespaceName:  &c.namespace,
+			BucketName:     &c.bucketName,
+			ObjectName:     &state.ObjectName,
+			UploadId:       &state.UploadID,
+			UploadPartNum:  &partNum,
+			ContentLength:  &size,
+			ContentMD5:     &contentMD5,
+			UploadPartBody: io.NopCloser(io.NewSectionReader(file, offset, size)),
+		}
+
+		response, err := c.objectStorageClient.UploadPart(ctx, request)
+		if err == nil {
+			return *response.ETag, nil
+		}
+		lastErr = err
+
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+	}
+
+	return "", fmt.Errorf("failed to upload part %d after %d attempts: %w", partNum, c.maxRetries, lastErr)
+}
+
+// loadUploadState returns the saved state for a resumable upload, or nil if
+// there is none or it no longer matches the file or the server. An upload
+// that no longer matches is aborted so its parts are not left behind.
+func (c *Client) loadUploadState(ctx context.Context, statePath string, fileInfo os.FileInfo, objectName string) (*uploadState, error) {
+	state, err := readUploadState(statePath)
+	if err != nil {
+		return nil, nil
+	}
+
+	// The local file changed since the upload started: start over
+	if state.ObjectName != objectName || state.FileSize != fileInfo.Size() ||
+		!state.ModTime.Equal(fileInfo.ModTime()) || state.PartSize <= 0 {
+		if err := c.abortMultipartUpload(ctx, state); err != nil && !isNotFound(err) {
+			return nil, fmt.Errorf("%w (run upload --abort or remove %s to start over)", err, statePath)
+		}
+		return nil, nil
+	}
+
+	// The server is the source of truth for which parts were received
+	parts, err := c.listUploadedParts(ctx, objectName, state.UploadID)
+	if err != nil {
+		return nil, nil
+	}
+
+	state.Parts = parts
+
+	return state, nil
+}
+
+// readUploadState reads the resume state file at statePath
+func readUploadState(statePath string) (*uploadState, error) {
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var state uploadState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid upload state file %s: %w", statePath, err)
+	}
+	return &state, nil
+}
+
+// PendingObjectName returns the object name of the interrupted multipart
+// upload of localPath, or an empty string if there is none. Uploads without an
+// explicit object name use it so a re-run resumes under the same name.
+func (c *Client) PendingObjectName(localPath string) string {
+	state, err := readUploadState(StateFilePath(localPath))
+	if err != nil {
+		return ""
+	}
+	return state.ObjectName
+}
+
+// listUploadedParts returns the parts already stored for a multipart upload
+func (c *Client) listUploadedParts(ctx context.Context, objectName string, uploadID string) (map[int]string, error) {
+	parts := make(map[int]string)
+
+	request := objectstorage.ListMultipartUploadPartsRequest{
+		NamespaceName: &c.namespace,
+		BucketName:    &c.bucketName,
+		ObjectName:    &objectName,
+		UploadId:      &uploadID,
+	}
+
+	for {
+		response, err := c.objectStorageClient.ListMultipartUploadParts(ctx, request)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list uploaded parts: %w", err)
+		}
+
+		for _, part := range response.Items {
+			if part.PartNumber != nil && part.Etag != nil {
+				parts[*part.PartNumber] = *part.Etag
+			}
+		}
+
+		if response.OpcNextPage == nil {
+			break
+		}
+		request.Page = response.OpcNextPage
+	}
+
+	return parts, nil
+}
+
+// saveUploadState atomically writes the resume state file
+func saveUploadState(statePath string, state *uploadState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode upload state: %w", err)
+	}
+
+	tmpPath := statePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write upload state: %w", err)
+	}
+	if err := os.Rename(tmpPath, statePath); err != nil {
+		return fmt.Errorf("failed to write upload state: %w", err)
+	}
+
+	return nil
+}
+
+// AbortUpload cancels an interrupted multipart upload recorded in the state
+// file for localPath and removes the state file
+func (c *Client) AbortUpload(ctx context.Context, localPath string) error {
+	statePath := StateFilePath(localPath)
+
+	state, err := readUploadState(statePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no interrupted upload found for %s", localPath)
+		}
+		return fmt.Errorf("failed to read upload state: %w", err)
+	}
+
+	if err := c.abortMultipartUpload(ctx, state); err != nil {
+		return err
+	}
+
+	return os.Remove(statePath)
+}
+
+// abortMultipartUpload cancels the multipart upload recorded in state and
+// deletes the parts stored for it
+func (c *Client) abortMultipartUpload(ctx context.Context, state *uploadState) error {
+	request := objectstorage.AbortMultipartUploadRequest{
+		NamespaceName: &c.namespace,
+		BucketName:    &c.bucketName,
+		ObjectName:    &state.ObjectName,
+		UploadId:      &state.UploadID,
+	}
+
+	if _, err := c.objectStorageClient.AbortMultipartUpload(ctx, request); err != nil {
+		return fmt.Errorf("failed to abort multipart upload %s: %w", state.UploadID, err)
+	}
+	return nil
+}
+
+// isNotFound reports whether err is a 404 from Object Storage
+func isNotFound(err error) bool {
+	var serviceErr common.ServiceError
+	return errors.As(err, &serviceErr) && serviceErr.GetHTTPStatusCode() == http.StatusNotFound
+}
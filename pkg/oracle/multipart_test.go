@@ -0,0 +1,388 @@
+package oracle
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+)
+
+// testData is 30 bytes: four parts of the fake client's 8-byte part size
+var testData = []byte("0123456789abcdefghijklmnopqrst")
+
+func writeTestFile(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "db.tar.gz")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func assertObject(t *testing.T, fake *fakeObjectStorage, name string, want []byte) {
+	t.Helper()
+	obj := fake.object(name)
+	if obj == nil {
+		t.Fatalf("object %s was not committed", name)
+	}
+	if !bytes.Equal(obj.data, want) {
+		t.Errorf("object content = %q, want %q", obj.data, want)
+	}
+	if got := lookupMeta(obj.meta, storage.MetaSHA256); got != sha256Hex(want) {
+		t.Errorf("sha256 metadata = %q, want %s", got, sha256Hex(want))
+	}
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func assertNoFile(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("%s exists", path)
+	}
+}
+
+func TestUploadMultipart(t *testing.T) {
+	fake := newFakeObjectStorage()
+	client := newFakeClient(t, fake)
+	path := writeTestFile(t, testData)
+
+	_, err := client.UploadFile(context.Background(), path, "backups/db.tar.gz", map[string]string{"backup-type": "postgres"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertObject(t, fake, "backups/db.tar.gz", testData)
+	if got := fake.object("backups/db.tar.gz").meta["backup-type"]; got != "postgres" {
+		t.Errorf("backup-type metadata = %q", got)
+	}
+	for partNum := 1; partNum <= 4; partNum++ {
+		if fake.partCalls[partNum] != 1 {
+			t.Errorf("part %d uploaded %d times", partNum, fake.partCalls[partNum])
+		}
+	}
+	assertNoFile(t, StateFilePath(path))
+}
+
+func TestUploadMultipartResumesAfterInterruption(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeObjectStorage()
+	client := newFakeClient(t, fake)
+	path := writeTestFile(t, testData)
+
+	// Part 3 fails on every attempt, so the first run stops after parts 1 and 2
+	fake.failPart = func(partNum, call int) bool { return partNum == 3 }
+	if _, err := client.UploadFile(ctx, path, "backups/db.tar.gz", nil); err == nil {
+		t.Fatal("upload with a failing part succeeded")
+	}
+	if fake.object("backups/db.tar.gz") != nil {
+		t.Fatal("interrupted upload was committed")
+	}
+	if _, err := os.Stat(StateFilePath(path)); err != nil {
+		t.Fatalf("state file not kept for resume: %v", err)
+	}
+
+	fake.failPart = nil
+	if _, err := client.UploadFile(ctx, path, "backups/db.tar.gz", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	assertObject(t, fake, "backups/db.tar.gz", testData)
+	if fake.created != 1 {
+		t.Errorf("created %d multipart uploads, want the first one resumed", fake.created)
+	}
+	want := map[int]int{1: 1, 2: 1, 3: client.maxRetries + 1, 4: 1}
+	for partNum, calls := range want {
+		if fake.partCalls[partNum] != calls {
+			t.Errorf("part %d uploaded %d times, want %d", partNum, fake.partCalls[partNum], calls)
+		}
+	}
+	assertNoFile(t, StateFilePath(path))
+}
+
+// TestUploadMultipartStateDisagreesWithServer checks that the parts listed by
+// the server, not the state file, decide what is uploaded on resume
+func TestUploadMultipartStateDisagreesWithServer(t *testing.T) {
+	tests := []struct {
+		name        string
+		serverParts []int          // parts already on the server
+		stateParts  map[int]string // parts recorded in the state file
+		lostUpload  bool           // the upload was aborted or expired
+		reuploaded  []int
+	}{
+		{
+			name:        "state lists parts the server lacks",
+			serverParts: []int{1},
+			stateParts:  map[int]string{1: "x", 2: "x", 3: "x"},
+			reuploaded:  []int{2, 3, 4},
+		},
+		{
+			name:        "server has parts missing from the state",
+			serverParts: []int{1, 2, 3},
+			stateParts:  map[int]string{1: "x"},
+			reuploaded:  []int{4},
+		},
+		{
+			name:        "upload no longer exists",
+			serverParts: []int{1, 2},
+			stateParts:  map[int]string{1: "x", 2: "x"},
+			lostUpload:  true,
+			reuploaded:  []int{1, 2, 3, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			fake := newFakeObjectStorage()
+			client := newFakeClient(t, fake)
+			path := writeTestFile(t, testData)
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Start an upload and store some parts directly on the server
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			objectName := "backups/db.tar.gz"
+			response, err := fake.CreateMultipartUpload(ctx, objectstorage.CreateMultipartUploadRequest{
+				CreateMultipartUploadDetails: objectstorage.CreateMultipartUploadDetails{
+					Object:   &objectName,
+					Metadata: multipartMeta(objectMeta(nil, sha256Hex(testData))),
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			state := &uploadState{
+				ObjectName: objectName,
+				UploadID:   *response.UploadId,
+				FileSize:   info.Size(),
+				ModTime:    info.ModTime(),
+				PartSize:   client.partSize,
+				Parts:      tt.stateParts,
+			}
+			for _, partNum := range tt.serverParts {
+				offset := int64(partNum-1) * state.PartSize
+				size := min(state.PartSize, state.FileSize-offset)
+				if _, err := client.uploadPartWithRetry(ctx, file, state, partNum, offset, size); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.lostUpload {
+				delete(fake.uploads, state.UploadID)
+			}
+			if err := saveUploadState(StateFilePath(path), state); err != nil {
+				t.Fatal(err)
+			}
+			before := make(map[int]int)
+			for partNum, calls := range fake.partCalls {
+				before[partNum] = calls
+			}
+
+			if _, err := client.UploadFile(ctx, path, "backups/db.tar.gz", nil); err != nil {
+				t.Fatal(err)
+			}
+
+			assertObject(t, fake, "backups/db.tar.gz", testData)
+			var reuploaded []int
+			for partNum := 1; partNum <= 4; partNum++ {
+				if fake.partCalls[partNum] > before[partNum] {
+					reuploaded = append(reuploaded, partNum)
+				}
+			}
+			if !slices.Equal(reuploaded, tt.reuploaded) {
+				t.Errorf("re-uploaded parts %v, want %v", reuploaded, tt.reuploaded)
+			}
+		})
+	}
+}
+
+func TestUploadMultipartRestartsWhenFileChanged(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeObjectStorage()
+	client := newFakeClient(t, fake)
+	path := writeTestFile(t, testData)
+
+	fake.failPart = func(partNum, call int) bool { return partNum == 3 }
+	if _, err := client.UploadFile(ctx, path, "backups/db.tar.gz", nil); err == nil {
+		t.Fatal("upload with a failing part succeeded")
+	}
+	stale, err := readUploadState(StateFilePath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Same size, new content: the uploaded parts must not be reused
+	changed := bytes.ToUpper(testData)
+	if err := os.WriteFile(path, changed, 0600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.failPart = nil
+	if _, err := client.UploadFile(ctx, path, "backups/db.tar.gz", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	assertObject(t, fake, "backups/db.tar.gz", changed)
+	if fake.created != 2 {
+		t.Errorf("created %d multipart uploads, want a new one for the changed file", fake.created)
+	}
+	// The stale upload is aborted rather than left holding billed parts
+	if !slices.Equal(fake.aborted, []string{stale.UploadID}) {
+		t.Errorf("aborted uploads %v, want %v", fake.aborted, []string{stale.UploadID})
+	}
+	if len(fake.uploads) != 0 {
+		t.Errorf("%d multipart uploads left open", len(fake.uploads))
+	}
+}
+
+func TestUploadMultipartStaleUploadAbortFails(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeObjectStorage()
+	client := newFakeClient(t, fake)
+	path := writeTestFile(t, testData)
+
+	fake.failPart = func(partNum, call int) bool { return partNum == 3 }
+	if _, err := client.UploadFile(ctx, path, "backups/db.tar.gz", nil); err == nil {
+		t.Fatal("upload with a failing part succeeded")
+	}
+	stale, err := readUploadState(StateFilePath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A different object name no longer matches the state file. While the
+	// stale upload cannot be aborted its state must be kept for upload --abort
+	fake.failPart = nil
+	fake.failAbort = true
+	if _, err := client.UploadFile(ctx, path, "backups/other.tar.gz", nil); err == nil {
+		t.Fatal("upload started although the stale upload could not be aborted")
+	}
+	if state, err := readUploadState(StateFilePath(path)); err != nil || state.UploadID != stale.UploadID {
+		t.Fatalf("state file no longer records the stale upload (err %v)", err)
+	}
+
+	// An upload that is already gone needs no abort
+	fake.failAbort = false
+	delete(fake.uploads, stale.UploadID)
+	if _, err := client.UploadFile(ctx, path, "backups/other.tar.gz", nil); err != nil {
+		t.Fatal(err)
+	}
+	assertObject(t, fake, "backups/other.tar.gz", testData)
+}
+
+func TestUploadFileResumesUnderPendingName(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeObjectStorage()
+	client := newFakeClient(t, fake)
+	path := writeTestFile(t, testData)
+
+	// The first run chose last month's object name
+	fake.failPart = func(partNum, call int) bool { return partNum == 3 }
+	if _, err := storage.UploadFile(ctx, client, path, "backups/2025/11/db.tar.gz", nil); err == nil {
+		t.Fatal("upload with a failing part succeeded")
+	}
+	if got := client.PendingObjectName(path); got != "backups/2025/11/db.tar.gz" {
+		t.Fatalf("PendingObjectName = %q", got)
+	}
+
+	fake.failPart = nil
+	result, err := storage.UploadFile(ctx, client, path, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ObjectName != "backups/2025/11/db.tar.gz" {
+		t.Errorf("resumed as %s, want the interrupted object name", result.ObjectName)
+	}
+	assertObject(t, fake, "backups/2025/11/db.tar.gz", testData)
+	if fake.created != 1 || len(fake.aborted) != 0 {
+		t.Errorf("created %d and aborted %d uploads, want the first one resumed", fake.created, len(fake.aborted))
+	}
+	if got := client.PendingObjectName(path); got != "" {
+		t.Errorf("PendingObjectName = %q after the upload finished", got)
+	}
+}
+
+func TestUploadPartRetriesCorruptedPart(t *testing.T) {
+	fake := newFakeObjectStorage()
+	client := newFakeClient(t, fake)
+	path := writeTestFile(t, testData)
+
+	// Part 2 is damaged in transit once; its Content-MD5 makes the server reject it
+	fake.corruptPart = func(partNum, call int) bool { return partNum == 2 && call == 1 }
+	if _, err := client.UploadFile(context.Background(), path, "backups/db.tar.gz", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	assertObject(t, fake, "backups/db.tar.gz", testData)
+	if fake.partCalls[2] != 2 {
+		t.Errorf("part 2 uploaded %d times, want 2", fake.partCalls[2])
+	}
+}
+
+func TestUploadPartGivesUpAfterMaxRetries(t *testing.T) {
+	fake := newFakeObjectStorage()
+	client := newFakeClient(t, fake)
+	path := writeTestFile(t, testData)
+
+	fake.corruptPart = func(partNum, call int) bool { return partNum == 1 }
+	if _, err := client.UploadFile(context.Background(), path, "backups/db.tar.gz", nil); err == nil {
+		t.Fatal("upload of a part that is always corrupted succeeded")
+	}
+	if fake.partCalls[1] != client.maxRetries {
+		t.Errorf("part 1 attempted %d times, want %d", fake.partCalls[1], client.maxRetries)
+	}
+}
+
+func TestStatLeavesMultipartMD5Empty(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeObjectStorage()
+	client := newFakeClient(t, fake)
+
+	// Larger than the fake part size: committed as a multipart upload
+	if _, err := client.UploadFile(ctx, writeTestFile(t, testData), "backups/large.tar.gz", nil); err != nil {
+		t.Fatal(err)
+	}
+	small := []byte("tiny")
+	fake.putObject("backups/small.tar.gz", small, nil)
+
+	info, err := client.Stat(ctx, "backups/large.tar.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.MD5 != "" {
+		t.Errorf("multipart object MD5 = %q, want empty", info.MD5)
+	}
+
+	info, err = client.Stat(ctx, "backups/small.tar.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := md5.Sum(small)
+	if want := base64.StdEncoding.EncodeToString(sum[:]); info.MD5 != want {
+		t.Errorf("single part object MD5 = %q, want %q", info.MD5, want)
+	}
+}
@@ -0,0 +1,145 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+)
+
+// SharedLink describes a pre-authenticated request (PAR) granting read access
+// to a single object without OCI credentials
+type SharedLink struct {
+	ID          string
+	Name        string
+	ObjectName  string
+	URL         string // Full HTTPS URL; only known when the link is created
+	TimeCreated time.Time
+	TimeExpires time.Time
+}
+
+// CreateSharedLink creates a read-only pre-authenticated request for an object
+// that expires after ttl. If name is empty one is derived from the object name.
+func (c *Client) CreateSharedLink(ctx context.Context, objectName string, ttl time.Duration, name string) (*SharedLink, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("expiry must be positive")
+	}
+
+	// Fail early with a clear message instead of sharing a missing object
+	info, err := c.Stat(ctx, objectName)
+	if err != nil {
+		return nil, err
+	}
+
+	// A link to an archived object would only return errors until it is restored
+	if info.NeedsRehydration() {
+		return nil, fmt.Errorf("%w: %s is %s, rehydrate it before sharing", storage.ErrObjectArchived, objectName, info.ArchivalState)
+	}
+
+	if name == "" {
+		name = fmt.Sprintf("share-%s-%s", path.Base(objectName), time.Now().UTC().Format("20060102-150405"))
+	}
+
+	request := objectstorage.CreatePreauthenticatedRequestRequest{
+		NamespaceName: &c.namespace,
+		BucketName:    &c.bucketName,
+		CreatePreauthenticatedRequestDetails: objectstorage.CreatePreauthenticatedRequestDetails{
+			Name:        &name,
+			ObjectName:  &objectName,
+			AccessType:  objectstorage.CreatePreauthenticatedRequestDetailsAccessTypeObjectread,
+			TimeExpires: &common.SDKTime{Time: time.Now().Add(ttl)},
+		},
+	}
+
+	response, err := c.objectStorageClient.CreatePreauthenticatedRequest(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pre-authenticated request: %w", err)
+	}
+
+	par := response.PreauthenticatedRequest
+	link := &SharedLink{
+		ID:         *par.Id,
+		Name:       *par.Name,
+		ObjectName: objectName,
+	}
+	if par.TimeCreated != nil {
+		link.TimeCreated = par.TimeCreated.Time
+	}
+	if par.TimeExpires != nil {
+		link.TimeExpires = par.TimeExpires.Time
+	}
+
+	// Older API versions only return the path relative to the endpoint
+	if par.FullPath != nil && *par.FullPath != "" {
+		link.URL = *par.FullPath
+	} else if par.AccessUri != nil {
+		link.URL = strings.TrimSuffix(c.objectStorageClient.Endpoint(), "/") + *par.AccessUri
+	}
+
+	return link, nil
+}
+
+// ListSharedLinks lists the pre-authenticated requests in the bucket for
+// objects whose name starts with prefix
+func (c *Client) ListSharedLinks(ctx context.Context, prefix string) ([]SharedLink, error) {
+	var links []SharedLink
+
+	request := objectstorage.ListPreauthenticatedRequestsRequest{
+		NamespaceName: &c.namespace,
+		BucketName:    &c.bucketName,
+	}
+	if prefix != "" {
+		request.ObjectNamePrefix = &prefix
+	}
+
+	for {
+		response, err := c.objectStorageClient.ListPreauthenticatedRequests(ctx, request)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pre-authenticated requests: %w", err)
+		}
+
+		for _, par := range response.Items {
+			link := SharedLink{
+				ID:   *par.Id,
+				Name: *par.Name,
+			}
+			if par.ObjectName != nil {
+				link.ObjectName = *par.ObjectName
+			}
+			if par.TimeCreated != nil {
+				link.TimeCreated = par.TimeCreated.Time
+			}
+			if par.TimeExpires != nil {
+				link.TimeExpires = par.TimeExpires.Time
+			}
+			links = append(links, link)
+		}
+
+		if response.OpcNextPage == nil || *response.OpcNextPage == "" {
+			break
+		}
+		request.Page = response.OpcNextPage
+	}
+
+	return links, nil
+}
+
+// RevokeSharedLink deletes a pre-authenticated request so its URL stops working
+func (c *Client) RevokeSharedLink(ctx context.Context, id string) error {
+	request := objectstorage.DeletePreauthenticatedRequestRequest{
+		NamespaceName: &c.namespace,
+		BucketName:    &c.bucketName,
+		ParId:         &id,
+	}
+
+	if _, err := c.objectStorageClient.DeletePreauthenticatedRequest(ctx, request); err != nil {
+		return fmt.Errorf("failed to revoke pre-authenticated request %s: %w", id, err)
+	}
+
+	return nil
+}
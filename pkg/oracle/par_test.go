@@ -0,0 +1,59 @@
+package oracle
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+)
+
+func TestCreateSharedLink(t *testing.T) {
+	fake := newFakeObjectStorage()
+	client := newFakeClient(t, fake)
+	fake.putObject("backups/2025/12/prod-db.tar.gz", testData, nil)
+
+	link, err := client.CreateSharedLink(context.Background(), "backups/2025/12/prod-db.tar.gz", time.Hour, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(link.Name, "share-prod-db.tar.gz-") {
+		t.Errorf("default name = %q, want share-prod-db.tar.gz-<time>", link.Name)
+	}
+	if want := "https://objectstorage.example.com/p/secret/n/namespace/b/backups/o/backups/2025/12/prod-db.tar.gz"; link.URL != want {
+		t.Errorf("URL = %s, want %s", link.URL, want)
+	}
+	details := fake.pars[0]
+	if details.AccessType != objectstorage.CreatePreauthenticatedRequestDetailsAccessTypeObjectread {
+		t.Errorf("access type = %s, want read-only", details.AccessType)
+	}
+	if until := time.Until(details.TimeExpires.Time); until <= 0 || until > time.Hour {
+		t.Errorf("link expires in %s, want within an hour", until)
+	}
+}
+
+func TestCreateSharedLinkRefusesArchivedObjects(t *testing.T) {
+	for _, state := range []objectstorage.HeadObjectArchivalStateEnum{
+		objectstorage.HeadObjectArchivalStateArchived,
+		objectstorage.HeadObjectArchivalStateRestoring,
+	} {
+		t.Run(string(state), func(t *testing.T) {
+			fake := newFakeObjectStorage()
+			client := newFakeClient(t, fake)
+			fake.putObject("backups/db.tar.gz", testData, nil)
+			fake.object("backups/db.tar.gz").archivalState = state
+
+			_, err := client.CreateSharedLink(context.Background(), "backups/db.tar.gz", time.Hour, "")
+			if !errors.Is(err, storage.ErrObjectArchived) {
+				t.Fatalf("err = %v, want ErrObjectArchived", err)
+			}
+			if len(fake.pars) != 0 {
+				t.Error("a link was created for an archived object")
+			}
+		})
+	}
+}
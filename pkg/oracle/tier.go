@@ -0,0 +1,123 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+)
+
+// DefaultRestoreHours is how long a rehydrated object stays readable
+const DefaultRestoreHours = 24
+
+// DefaultPollInterval is how often Rehydrate checks a restore when no
+// interval is given
+const DefaultPollInterval = 5 * time.Minute
+
+// ParseStorageTier converts a tier name (standard, infrequent, archive) to the OCI enum
+// An empty name returns an empty tier, meaning the bucket default
+func ParseStorageTier(name string) (objectstorage.StorageTierEnum, error) {
+	switch strings.ToLower(strings.ReplaceAll(name, "-", "")) {
+	case "":
+		return "", nil
+	case "standard":
+		return objectstorage.StorageTierStandard, nil
+	case "infrequent", "infrequentaccess":
+		return objectstorage.StorageTierInfrequentAccess, nil
+	case "archive":
+		return objectstorage.StorageTierArchive, nil
+	default:
+		return "", fmt.Errorf("unsupported storage tier: %s (supported: standard, infrequent, archive)", name)
+	}
+}
+
+// SetStorageTier moves an existing object to another storage tier
+func (c *Client) SetStorageTier(ctx context.Context, objectName string, tier objectstorage.StorageTierEnum) error {
+	request := objectstorage.UpdateObjectStorageTierRequest{
+		NamespaceName: &c.namespace,
+		BucketName:    &c.bucketName,
+		UpdateObjectStorageTierDetails: objectstorage.UpdateObjectStorageTierDetails{
+			ObjectName:  &objectName,
+			StorageTier: tier,
+		},
+	}
+
+	if _, err := c.objectStorageClient.UpdateObjectStorageTier(ctx, request); err != nil {
+		return fmt.Errorf("failed to change storage tier of %s: %w", objectName, err)
+	}
+
+	return nil
+}
+
+// RequestRestore asks OCI to rehydrate an archived object for the given number
+// of hours. It returns immediately; use Rehydrate to wait for completion.
+func (c *Client) RequestRestore(ctx context.Context, objectName string, hours int) error {
+	if hours <= 0 {
+		hours = DefaultRestoreHours
+	}
+
+	request := objectstorage.RestoreObjectsRequest{
+		NamespaceName: &c.namespace,
+		BucketName:    &c.bucketName,
+		RestoreObjectsDetails: objectstorage.RestoreObjectsDetails{
+			ObjectName: &objectName,
+			Hours:      &hours,
+		},
+	}
+
+	if _, err := c.objectStorageClient.RestoreObjects(ctx, request); err != nil {
+		return fmt.Errorf("failed to restore archived object %s: %w", objectName, err)
+	}
+
+	return nil
+}
+
+// Rehydrate makes an archived object readable: it issues a restore request if
+// one is not already running and polls every pollInterval until it completes.
+// Objects that are not archived return immediately. A pollInterval <= 0 uses
+// DefaultPollInterval.
+func (c *Client) Rehydrate(ctx context.Context, objectName string, hours int, pollInterval time.Duration) error {
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+
+	info, err := c.Stat(ctx, objectName)
+	if err != nil {
+		return err
+	}
+
+	if !info.NeedsRehydration() {
+		return nil
+	}
+
+	if info.ArchivalState == storage.ArchivalStateArchived {
+		if err := c.RequestRestore(ctx, objectName, hours); err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for %s to be restored from archive: %w", objectName, ctx.Err())
+		case <-ticker.C:
+		}
+
+		info, err := c.Stat(ctx, objectName)
+		if err != nil {
+			return err
+		}
+		if !info.NeedsRehydration() {
+			return nil
+		}
+	}
+}
+
+// Ensure Client supports archive rehydration
+var _ storage.Rehydrator = (*Client)(nil)
@@ -2,27 +2,25 @@ package oracle
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
 	"time"
 
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
 	"github.com/oracle/oci-go-sdk/v65/objectstorage"
 )
 
 // UploadResult contains information about the uploaded file
-type UploadResult struct {
-	ObjectName string
-	BucketName string
-	Namespace  string
-	Size       int64
-	Duration   time.Duration
-	ETag       string
-}
+type UploadResult = storage.UploadResult
 
 // UploadFile uploads a local file to Oracle Cloud Object Storage
-// It reads the file from localPath and uploads it with the given objectName
-func (c *Client) UploadFile(ctx context.Context, localPath string, objectName string) (*UploadResult, error) {
+// It reads the file from localPath and uploads it with the given objectName,
+// storing metadata as opc-meta-* headers.
+// Files larger than the configured part size use a resumable multipart upload.
+func (c *Client) UploadFile(ctx context.Context, localPath string, objectName string, metadata map[string]string) (*UploadResult, error) {
 	startTime := time.Now()
 
 	// Open the file
@@ -38,14 +36,31 @@ func (c *Client) UploadFile(ctx context.Context, localPath string, objectName st
 		return nil, fmt.Errorf("failed to get file info: %w", err)
 	}
 
-	// If object name is not provided, use the filename
-	if objectName == "" {
-		objectName = filepath.Base(localPath)
-	}
-
 	// Get file size for ContentLength
 	fileSize := fileInfo.Size()
 
+	// Large files are uploaded in parallel parts that can be resumed
+	if fileSize > c.partSize {
+		etag, err := c.uploadMultipart(ctx, file, fileInfo, objectName, metadata)
+		if err != nil {
+			return nil, err
+		}
+
+		return &UploadResult{
+			ObjectName: objectName,
+			Location:   c.Location(),
+			Size:       fileSize,
+			Duration:   time.Since(startTime),
+			ETag:       etag,
+		}, nil
+	}
+
+	// Record a SHA-256 so downloads can be verified end to end
+	checksum, err := fileSHA256(file, fileSize)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create the put object request
 	request := objectstorage.PutObjectRequest{
 		NamespaceName: &c.namespace,
@@ -53,6 +68,8 @@ func (c *Client) UploadFile(ctx context.Context, localPath string, objectName st
 		ObjectName:    &objectName,
 		ContentLength: &fileSize,
 		PutObjectBody: file,
+		OpcMeta:       objectMeta(metadata, checksum),
+		StorageTier:   objectstorage.PutObjectStorageTierEnum(c.storageTier),
 	}
 
 	// Upload the file
@@ -65,8 +82,7 @@ func (c *Client) UploadFile(ctx context.Context, localPath string, objectName st
 
 	result := &UploadResult{
 		ObjectName: objectName,
-		BucketName: c.bucketName,
-		Namespace:  c.namespace,
+		Location:   c.Location(),
 		Size:       fileInfo.Size(),
 		Duration:   duration,
 		ETag:       *response.ETag,
@@ -78,12 +94,18 @@ func (c *Client) UploadFile(ctx context.Context, localPath string, objectName st
 // UploadBackup is a convenience function that uploads a backup file
 // It automatically generates the object name from the local file path
 func (c *Client) UploadBackup(ctx context.Context, backupPath string) (*UploadResult, error) {
-	// Extract filename from path for object name
-	filename := filepath.Base(backupPath)
-
 	// Create a folder structure: backups/YYYY/MM/filename
-	now := time.Now()
-	objectName := fmt.Sprintf("backups/%d/%02d/%s", now.Year(), now.Month(), filename)
+	objectName := storage.BackupObjectName(backupPath, time.Now())
 
-	return c.UploadFile(ctx, backupPath, objectName)
+	return c.UploadFile(ctx, backupPath, objectName, nil)
+}
+
+// fileSHA256 returns the hex SHA-256 of the first size bytes of file
+// It does not move the file offset
+func fileSHA256(file *os.File, size int64) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, io.NewSectionReader(file, 0, size)); err != nil {
+		return "", fmt.Errorf("failed to checksum file: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
 }
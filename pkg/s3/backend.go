@@ -0,0 +1,124 @@
+package s3
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
+	"github.com/minio/minio-go/v7"
+)
+
+// Ensure Client satisfies the storage interfaces
+var (
+	_ storage.Backend        = (*Client)(nil)
+	_ storage.Walker         = (*Client)(nil)
+	_ storage.FileUploader   = (*Client)(nil)
+	_ storage.FileDownloader = (*Client)(nil)
+)
+
+// Location returns the s3:// URI of the configured bucket and its endpoint
+func (c *Client) Location() string {
+	return fmt.Sprintf("s3://%s (%s)", c.bucketName, c.endpoint)
+}
+
+// Put streams body into an object in the bucket
+// A size of -1 is allowed and results in a streaming multipart upload.
+// Seekable bodies are checksummed first so downloads can be verified.
+func (c *Client) Put(ctx context.Context, objectName string, body io.Reader, size int64, metadata map[string]string) (*storage.ObjectInfo, error) {
+	var checksum string
+	if seeker, ok := body.(io.ReadSeeker); ok {
+		sum, err := readerSHA256(seeker)
+		if err != nil {
+			return nil, err
+		}
+		checksum = sum
+	}
+
+	info, err := c.minioClient.PutObject(ctx, c.bucketName, objectName, body, size, minio.PutObjectOptions{
+		ContentType:  "application/octet-stream",
+		UserMetadata: objectMeta(metadata, checksum),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload object %s: %w", objectName, err)
+	}
+
+	return &storage.ObjectInfo{
+		Name:         objectName,
+		Size:         info.Size,
+		LastModified: info.LastModified,
+		ETag:         info.ETag,
+	}, nil
+}
+
+// Get opens an object in the bucket for reading
+func (c *Client) Get(ctx context.Context, objectName string) (io.ReadCloser, *storage.ObjectInfo, error) {
+	obj, err := c.minioClient.GetObject(ctx, c.bucketName, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download object %s: %w", objectName, err)
+	}
+
+	// GetObject is lazy; Stat forces the request so missing objects fail here
+	stat, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, nil, fmt.Errorf("failed to download object %s: %w", objectName, err)
+	}
+
+	return obj, &storage.ObjectInfo{
+		Name:         objectName,
+		Size:         stat.Size,
+		LastModified: stat.LastModified,
+		ETag:         stat.ETag,
+	}, nil
+}
+
+// List returns all objects whose name starts with prefix
+func (c *Client) List(ctx context.Context, prefix string) ([]storage.ObjectInfo, error) {
+	return c.ListObjects(ctx, prefix)
+}
+
+// Delete removes an object from the bucket
+func (c *Client) Delete(ctx context.Context, objectName string) error {
+	if err := c.minioClient.RemoveObject(ctx, c.bucketName, objectName, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete object %s: %w", objectName, err)
+	}
+	return nil
+}
+
+// Stat returns information about an object using a HEAD request
+func (c *Client) Stat(ctx context.Context, objectName string) (*storage.ObjectInfo, error) {
+	stat, err := c.minioClient.StatObject(ctx, c.bucketName, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat object %s: %w", objectName, err)
+	}
+
+	return &storage.ObjectInfo{
+		Name:         objectName,
+		Size:         stat.Size,
+		LastModified: stat.LastModified,
+		ETag:         stat.ETag,
+		StorageTier:  stat.StorageClass,
+		Metadata:     storage.NormalizeMetadata(stat.UserMetadata),
+	}, nil
+}
+
+// readerSHA256 returns the hex SHA-256 of the rest of r and seeks back to
+// where it started
+func readerSHA256(r io.ReadSeeker) (string, error) {
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", fmt.Errorf("failed to checksum object: %w", err)
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", fmt.Errorf("failed to checksum object: %w", err)
+	}
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to checksum object: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,107 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// Client represents an S3-compatible client (AWS S3, MinIO, Ceph RGW) for object operations
+type Client struct {
+	minioClient *minio.Client
+	endpoint    string
+	bucketName  string
+}
+
+// Config holds the configuration for an S3-compatible client
+type Config struct {
+	Endpoint        string // host[:port], optionally prefixed with http:// or https://
+	Region          string
+	BucketName      string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	UsePathStyle    bool // Required by most MinIO and Ceph RGW deployments
+	Insecure        bool // Use plain HTTP instead of HTTPS
+}
+
+// NewClient creates a new S3-compatible client
+// Credentials are taken from Config, falling back to the AWS/MinIO environment
+// variables, the shared AWS credentials file and finally the EC2/ECS IAM role
+func NewClient(config Config) (*Client, error) {
+	if config.BucketName == "" {
+		return nil, fmt.Errorf("bucket name is required")
+	}
+
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = "s3.amazonaws.com"
+	}
+
+	// Allow the scheme to be given as part of the endpoint
+	secure := !config.Insecure
+	if strings.HasPrefix(endpoint, "http://") {
+		endpoint = strings.TrimPrefix(endpoint, "http://")
+		secure = false
+	} else if strings.HasPrefix(endpoint, "https://") {
+		endpoint = strings.TrimPrefix(endpoint, "https://")
+		secure = true
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+
+	var creds *credentials.Credentials
+	if config.AccessKeyID != "" {
+		creds = credentials.NewStaticV4(config.AccessKeyID, config.SecretAccessKey, config.SessionToken)
+	} else {
+		creds = credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.EnvMinio{},
+			&credentials.FileAWSCredentials{Profile: os.Getenv("AWS_PROFILE")},
+			&credentials.IAM{},
+		})
+	}
+
+	bucketLookup := minio.BucketLookupAuto
+	if config.UsePathStyle {
+		bucketLookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:        creds,
+		Secure:       secure,
+		Region:       config.Region,
+		BucketLookup: bucketLookup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	// Verify the bucket is reachable so misconfiguration fails early
+	exists, err := client.BucketExists(context.Background(), config.BucketName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access bucket %s: %w", config.BucketName, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("bucket does not exist: %s", config.BucketName)
+	}
+
+	return &Client{
+		minioClient: client,
+		endpoint:    endpoint,
+		bucketName:  config.BucketName,
+	}, nil
+}
+
+// GetBucketName returns the configured bucket name
+func (c *Client) GetBucketName() string {
+	return c.bucketName
+}
+
+// GetEndpoint returns the S3 endpoint in use
+func (c *Client) GetEndpoint() string {
+	return c.endpoint
+}
@@ -0,0 +1,73 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
+	"github.com/minio/minio-go/v7"
+)
+
+// DownloadResult contains information about the downloaded file
+type DownloadResult = storage.DownloadResult
+
+// DownloadFile downloads an object from the S3 bucket to a local file
+// The object is written to localPath + ".part" and only renamed into place once
+// it matches the SHA-256 recorded on upload; ErrChecksumMismatch is returned
+// otherwise. Objects uploaded without a checksum are not verified.
+func (c *Client) DownloadFile(ctx context.Context, objectName string, localPath string) (*DownloadResult, error) {
+	startTime := time.Now()
+
+	info, err := c.minioClient.StatObject(ctx, c.bucketName, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat object %s: %w", objectName, err)
+	}
+
+	// Pin the version that was checked so an overwrite mid-download fails
+	// instead of mixing objects
+	opts := minio.GetObjectOptions{}
+	if err := opts.SetMatchETag(info.ETag); err != nil {
+		return nil, fmt.Errorf("failed to download object %s: %w", objectName, err)
+	}
+
+	partPath := localPath + ".part"
+	if err := c.minioClient.FGetObject(ctx, c.bucketName, objectName, partPath, opts); err != nil {
+		return nil, fmt.Errorf("failed to download object %s: %w", objectName, err)
+	}
+
+	verified := ""
+	if expected := storage.NormalizeMetadata(info.UserMetadata)[storage.MetaSHA256]; expected != "" {
+		actual, err := fileSHA256(partPath)
+		if err != nil {
+			os.Remove(partPath)
+			return nil, err
+		}
+		if actual != expected {
+			os.Remove(partPath)
+			return nil, fmt.Errorf("%w: sha256 expected %s, got %s", storage.ErrChecksumMismatch, expected, actual)
+		}
+		verified = "sha256"
+	}
+
+	if err := os.Rename(partPath, localPath); err != nil {
+		os.Remove(partPath)
+		return nil, fmt.Errorf("failed to move download into place: %w", err)
+	}
+
+	return &DownloadResult{
+		ObjectName:   objectName,
+		LocalPath:    localPath,
+		Size:         info.Size,
+		Duration:     time.Since(startTime),
+		LastModified: info.LastModified,
+		Verified:     verified,
+	}, nil
+}
+
+// DownloadBackup is a convenience function that downloads a backup file
+// It downloads from the specified object name to the local path
+func (c *Client) DownloadBackup(ctx context.Context, objectName string, localPath string) (*DownloadResult, error) {
+	return c.DownloadFile(ctx, objectName, localPath)
+}
@@ -0,0 +1,219 @@
+package s3
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
+)
+
+// fakeObject is an object stored by the fake S3 server
+type fakeObject struct {
+	data   []byte
+	header http.Header // x-amz-meta-* headers sent with the upload
+}
+
+// newObjectServer fakes the single-part PUT, HEAD and GET object requests of
+// the bucket "backups" and returns a client for it and the stored objects
+func newObjectServer(t *testing.T) (*Client, map[string]*fakeObject) {
+	t.Helper()
+
+	var mu sync.Mutex
+	objects := make(map[string]*fakeObject)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/backups/")
+		if key == "" || key == r.URL.Path {
+			// BucketExists in NewClient
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Method {
+		case http.MethodPut:
+			data, err := readPayload(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			obj := &fakeObject{data: data, header: http.Header{}}
+			for name, values := range r.Header {
+				if strings.HasPrefix(strings.ToLower(name), "x-amz-meta-") {
+					obj.header[name] = values
+				}
+			}
+			objects[key] = obj
+			w.Header().Set("ETag", `"`+strconv.Itoa(len(data))+`"`)
+		case http.MethodHead, http.MethodGet:
+			obj, ok := objects[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			for name, values := range obj.header {
+				w.Header()[name] = values
+			}
+			w.Header().Set("ETag", `"`+strconv.Itoa(len(obj.data))+`"`)
+			w.Header().Set("Last-Modified", "Tue, 09 Dec 2025 09:26:58 GMT")
+			w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+			if r.Method == http.MethodGet {
+				w.Write(obj.data)
+			}
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(Config{
+		Endpoint:        server.URL,
+		Region:          "us-east-1",
+		BucketName:      "backups",
+		AccessKeyID:     "test",
+		SecretAccessKey: "test",
+		UsePathStyle:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, objects
+}
+
+// readPayload returns the body of a PUT, decoding the aws-chunked encoding
+// minio-go uses for signed uploads over plain HTTP
+func readPayload(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var data []byte
+	reader := bufio.NewReader(r.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return data, nil
+		}
+		chunk := make([]byte, size+2) // data and its trailing CRLF
+		if _, err := io.ReadFull(reader, chunk); err != nil {
+			return nil, err
+		}
+		data = append(data, chunk[:size]...)
+	}
+}
+
+func TestUploadRecordsSHA256(t *testing.T) {
+	client, objects := newObjectServer(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "db.tar.gz")
+	content := bytes.Repeat([]byte("backup data\n"), 1000)
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	sum, err := fileSHA256(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.UploadFile(ctx, path, "backups/db.tar.gz", map[string]string{"Backup-Type": "postgres"}); err != nil {
+		t.Fatal(err)
+	}
+	obj := objects["backups/db.tar.gz"]
+	if obj == nil {
+		t.Fatal("object not uploaded")
+	}
+	if got := obj.header.Get("X-Amz-Meta-" + storage.MetaSHA256); got != sum {
+		t.Errorf("x-amz-meta-sha256 = %q, want %q", got, sum)
+	}
+	if got := obj.header.Get("X-Amz-Meta-Backup-Type"); got != "postgres" {
+		t.Errorf("x-amz-meta-backup-type = %q, want postgres", got)
+	}
+
+	// Put checksums seekable bodies too
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := client.Put(ctx, "backups/put.tar.gz", file, int64(len(content)), nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := objects["backups/put.tar.gz"].header.Get("X-Amz-Meta-" + storage.MetaSHA256); got != sum {
+		t.Errorf("Put stored sha256 %q, want %q", got, sum)
+	}
+	if !bytes.Equal(objects["backups/put.tar.gz"].data, content) {
+		t.Error("Put uploaded different content after checksumming the body")
+	}
+}
+
+func TestDownloadVerifiesSHA256(t *testing.T) {
+	client, objects := newObjectServer(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "db.tar.gz")
+	content := bytes.Repeat([]byte("backup data\n"), 1000)
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.UploadFile(ctx, path, "backups/db.tar.gz", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := filepath.Join(dir, "restored.tar.gz")
+	result, err := client.DownloadFile(ctx, "backups/db.tar.gz", restored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Verified != "sha256" {
+		t.Errorf("Verified = %q, want sha256", result.Verified)
+	}
+	if data, err := os.ReadFile(restored); err != nil || !bytes.Equal(data, content) {
+		t.Errorf("downloaded content differs (err %v)", err)
+	}
+
+	// Corruption at rest is caught and nothing is left under either name
+	objects["backups/db.tar.gz"].data[0] ^= 0xff
+	corrupted := filepath.Join(dir, "corrupted.tar.gz")
+	_, err = client.DownloadFile(ctx, "backups/db.tar.gz", corrupted)
+	if !errors.Is(err, storage.ErrChecksumMismatch) {
+		t.Fatalf("err = %v, want %v", err, storage.ErrChecksumMismatch)
+	}
+	for _, p := range []string{corrupted, corrupted + ".part"} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s exists after a failed download", p)
+		}
+	}
+
+	// Objects uploaded without a checksum still download, unverified
+	delete(objects["backups/db.tar.gz"].header, "X-Amz-Meta-Sha256")
+	result, err = client.DownloadFile(ctx, "backups/db.tar.gz", corrupted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Verified != "" {
+		t.Errorf("Verified = %q for an object without a checksum", result.Verified)
+	}
+}
@@ -0,0 +1,126 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
+	"github.com/minio/minio-go/v7"
+)
+
+// ObjectInfo contains information about an object in the S3 bucket
+type ObjectInfo = storage.ObjectInfo
+
+// metadataBatchSize is the number of listed objects whose metadata is fetched
+// together when a walk requests it
+const metadataBatchSize = 100
+
+// ListObjects lists all objects in the bucket with an optional prefix
+// Pagination is handled by the underlying client
+func (c *Client) ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+
+	opts := minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	}
+
+	for obj := range c.minioClient.ListObjects(ctx, c.bucketName, opts) {
+		if obj.Err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", obj.Err)
+		}
+		objects = append(objects, ObjectInfo{
+			Name:         obj.Key,
+			Size:         obj.Size,
+			LastModified: obj.LastModified,
+			ETag:         obj.ETag,
+		})
+	}
+
+	return objects, nil
+}
+
+// ListBackups lists all backup files (objects with 'backups/' prefix)
+func (c *Client) ListBackups(ctx context.Context) ([]ObjectInfo, error) {
+	return c.ListObjects(ctx, storage.BackupsPrefix)
+}
+
+// ListBackupsByDate lists backups for a specific year and month
+func (c *Client) ListBackupsByDate(ctx context.Context, year int, month int) ([]ObjectInfo, error) {
+	return c.ListObjects(ctx, storage.BackupDatePrefix(year, month))
+}
+
+// Walk calls fn for every object matching opts as pages arrive from the server
+// When metadata is requested, objects are collected into batches whose
+// metadata is fetched with concurrent HEAD requests.
+func (c *Client) Walk(ctx context.Context, opts storage.ListOptions, fn storage.WalkFunc) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	listOpts := minio.ListObjectsOptions{
+		Prefix:     opts.Prefix,
+		Recursive:  true,
+		MaxKeys:    opts.PageSize,
+		StartAfter: listStartAfter(opts),
+	}
+
+	batchSize := 1
+	if opts.HasField(storage.FieldMetadata) {
+		batchSize = metadataBatchSize
+	}
+	batch := make([]ObjectInfo, 0, batchSize)
+
+	flush := func() error {
+		if opts.HasField(storage.FieldMetadata) {
+			if err := storage.FetchMetadata(ctx, batch, c.Stat); err != nil {
+				return err
+			}
+		}
+		for _, info := range batch {
+			if err := fn(info); err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for obj := range c.minioClient.ListObjects(ctx, c.bucketName, listOpts) {
+		if obj.Err != nil {
+			return fmt.Errorf("failed to list objects: %w", obj.Err)
+		}
+
+		// Keys are returned in lexicographic order, so End terminates the walk
+		if opts.End != "" && obj.Key >= opts.End {
+			break
+		}
+		if !opts.Matches(obj.Key) {
+			continue
+		}
+
+		batch = append(batch, ObjectInfo{
+			Name:         obj.Key,
+			Size:         obj.Size,
+			LastModified: obj.LastModified,
+			ETag:         obj.ETag,
+			StorageTier:  obj.StorageClass,
+		})
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return flush()
+}
+
+// listStartAfter returns the StartAfter key that makes the server begin the
+// listing at opts.Start. StartAfter is exclusive, so Start minus its last
+// byte is used; the few keys between the two are dropped by opts.Matches.
+func listStartAfter(opts storage.ListOptions) string {
+	if opts.Start <= opts.Prefix {
+		return ""
+	}
+	return opts.Start[:len(opts.Start)-1]
+}
@@ -0,0 +1,124 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
+)
+
+// newListServer fakes ListObjectsV2 over keys and records the start-after
+// parameter of each request
+func newListServer(t *testing.T, keys []string, startAfter *[]string) *Client {
+	t.Helper()
+	sort.Strings(keys)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.Method == http.MethodHead {
+			// BucketExists in NewClient
+			return
+		}
+		if query.Get("list-type") != "2" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		after := query.Get("start-after")
+		*startAfter = append(*startAfter, after)
+
+		var b strings.Builder
+		b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">`)
+		b.WriteString(`<Name>backups</Name><IsTruncated>false</IsTruncated>`)
+		for _, key := range keys {
+			if !strings.HasPrefix(key, query.Get("prefix")) || key <= after {
+				continue
+			}
+			fmt.Fprintf(&b, `<Contents><Key>%s</Key><Size>1</Size><ETag>"x"</ETag><LastModified>2025-12-09T09:26:58.000Z</LastModified></Contents>`, key)
+		}
+		b.WriteString(`</ListBucketResult>`)
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(b.String()))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(Config{
+		Endpoint:        server.URL,
+		Region:          "us-east-1",
+		BucketName:      "backups",
+		AccessKeyID:     "test",
+		SecretAccessKey: "test",
+		UsePathStyle:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestWalkStartsListingAtStart(t *testing.T) {
+	keys := []string{
+		"backups/2025/10/a.tar.gz",
+		"backups/2025/11/b.tar.gz",
+		"backups/2025/11/c.tar.gz",
+		"backups/2025/12/d.tar.gz",
+		"backups/2026/01/e.tar.gz",
+	}
+
+	tests := []struct {
+		name       string
+		opts       storage.ListOptions
+		startAfter string
+		want       []string
+	}{
+		{
+			name: "no bounds",
+			opts: storage.ListOptions{Prefix: "backups/"},
+			want: keys,
+		},
+		{
+			name:       "start and end",
+			opts:       storage.ListOptions{Prefix: "backups/", Start: "backups/2025/11/", End: "backups/2025/12/"},
+			startAfter: "backups/2025/11",
+			want:       keys[1:3],
+		},
+		{
+			name:       "start is an object",
+			opts:       storage.ListOptions{Prefix: "backups/", Start: "backups/2025/12/d.tar.gz"},
+			startAfter: "backups/2025/12/d.tar.g",
+			want:       keys[3:],
+		},
+		{
+			name: "start before prefix",
+			opts: storage.ListOptions{Prefix: "backups/2025/", Start: "backups/"},
+			want: keys[:4],
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var startAfter []string
+			client := newListServer(t, append([]string(nil), keys...), &startAfter)
+
+			var got []string
+			err := client.Walk(context.Background(), tt.opts, func(obj storage.ObjectInfo) error {
+				got = append(got, obj.Name)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Walk returned %v, want %v", got, tt.want)
+			}
+			if len(startAfter) == 0 || startAfter[0] != tt.startAfter {
+				t.Errorf("start-after = %q, want %q", startAfter, tt.startAfter)
+			}
+		})
+	}
+}
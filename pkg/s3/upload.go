@@ -0,0 +1,83 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Kobeep/cloud-dr-orchestrator/pkg/storage"
+	"github.com/minio/minio-go/v7"
+)
+
+// UploadResult contains information about the uploaded file
+type UploadResult = storage.UploadResult
+
+// UploadFile uploads a local file to the S3 bucket
+// It reads the file from localPath and uploads it with the given objectName,
+// storing metadata as x-amz-meta-* headers
+func (c *Client) UploadFile(ctx context.Context, localPath string, objectName string, metadata map[string]string) (*UploadResult, error) {
+	startTime := time.Now()
+
+	// Get file info for size
+	fileInfo, err := os.Stat(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	// Record a SHA-256 so downloads can be verified end to end; the ETag of a
+	// multipart upload is not an MD5 of the content
+	checksum, err := fileSHA256(localPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// FPutObject switches to a multipart upload for large files
+	info, err := c.minioClient.FPutObject(ctx, c.bucketName, objectName, localPath, minio.PutObjectOptions{
+		ContentType:  "application/octet-stream",
+		UserMetadata: objectMeta(metadata, checksum),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload file to S3: %w", err)
+	}
+
+	return &UploadResult{
+		ObjectName: objectName,
+		Location:   c.Location(),
+		Size:       fileInfo.Size(),
+		Duration:   time.Since(startTime),
+		ETag:       info.ETag,
+	}, nil
+}
+
+// UploadBackup is a convenience function that uploads a backup file
+// It automatically generates the object name from the local file path
+func (c *Client) UploadBackup(ctx context.Context, backupPath string) (*UploadResult, error) {
+	// Create a folder structure: backups/YYYY/MM/filename
+	objectName := storage.BackupObjectName(backupPath, time.Now())
+
+	return c.UploadFile(ctx, backupPath, objectName, nil)
+}
+
+// fileSHA256 returns the hex SHA-256 of the file at path
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	return readerSHA256(file)
+}
+
+// objectMeta merges user metadata with the content checksum
+func objectMeta(metadata map[string]string, checksum string) map[string]string {
+	meta := storage.NormalizeMetadata(metadata)
+	if meta == nil {
+		meta = make(map[string]string, 1)
+	}
+	if checksum != "" {
+		meta[storage.MetaSHA256] = checksum
+	}
+	return meta
+}
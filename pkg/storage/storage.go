@@ -0,0 +1,407 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BackupsPrefix is the prefix under which all backups are organized
+const BackupsPrefix = "backups/"
+
+// WALPrefix is the prefix under which archived PostgreSQL WAL is stored
+const WALPrefix = "wal/"
+
+// MetaSHA256 is the object metadata key holding the hex SHA-256 of the content
+const MetaSHA256 = "sha256"
+
+// ErrObjectArchived is returned when an object must be rehydrated from an
+// archive tier before it can be read
+var ErrObjectArchived = errors.New("object is archived")
+
+// ErrChecksumMismatch is returned when downloaded data does not match the
+// checksum recorded for the object
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// Optional fields that can be requested when listing objects
+const (
+	FieldMD5           = "md5"
+	FieldStorageTier   = "storageTier"
+	FieldArchivalState = "archivalState"
+	FieldTimeCreated   = "timeCreated"
+	// FieldMetadata is not part of any list API: it costs one HEAD request
+	// per object, made MetadataConcurrency at a time
+	FieldMetadata = "metadata"
+)
+
+// MetadataConcurrency is the number of HEAD requests made at once to fetch
+// object metadata for a listing
+const MetadataConcurrency = 16
+
+// ObjectInfo contains information about an object held by a storage backend
+// Fields after ETag are only populated when requested or supported by the backend
+type ObjectInfo struct {
+	Name          string
+	Size          int64
+	LastModified  time.Time
+	ETag          string
+	MD5           string // Base64-encoded MD5 of the object data
+	StorageTier   string
+	ArchivalState string
+	TimeCreated   time.Time
+	Metadata      map[string]string
+}
+
+// Archival states reported for objects in an archive tier
+const (
+	ArchivalStateArchived  = "Archived"
+	ArchivalStateRestoring = "Restoring"
+	ArchivalStateRestored  = "Restored"
+)
+
+// NeedsRehydration reports whether the object must be restored from an
+// archive tier before it can be read
+func (o ObjectInfo) NeedsRehydration() bool {
+	return o.ArchivalState == ArchivalStateArchived || o.ArchivalState == ArchivalStateRestoring
+}
+
+// ListOptions controls a listing of objects
+type ListOptions struct {
+	Prefix   string
+	Start    string            // Only objects whose name is >= Start (inclusive)
+	End      string            // Only objects whose name is < End (exclusive)
+	Fields   []string          // Extra fields to populate, see the Field constants
+	PageSize int               // Objects fetched per request, 0 uses the backend default
+	Metadata map[string]string // Only objects whose metadata has all of these values
+}
+
+// Matches reports whether an object name falls within the prefix and bounds
+func (o ListOptions) Matches(name string) bool {
+	if !strings.HasPrefix(name, o.Prefix) {
+		return false
+	}
+	if o.Start != "" && name < o.Start {
+		return false
+	}
+	if o.End != "" && name >= o.End {
+		return false
+	}
+	return true
+}
+
+// HasField reports whether the given extra field was requested
+func (o ListOptions) HasField(field string) bool {
+	for _, f := range o.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesMetadata reports whether meta contains every key/value pair in
+// o.Metadata. Keys are compared case-insensitively.
+func (o ListOptions) MatchesMetadata(meta map[string]string) bool {
+	for key, value := range o.Metadata {
+		if meta[strings.ToLower(key)] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeMetadata returns a copy of meta with lowercase keys
+// Backends differ in how they case user metadata keys on the way back
+func NormalizeMetadata(meta map[string]string) map[string]string {
+	if meta == nil {
+		return nil
+	}
+	normalized := make(map[string]string, len(meta))
+	for key, value := range meta {
+		normalized[strings.ToLower(key)] = value
+	}
+	return normalized
+}
+
+// WalkFunc is called for every object during a walk
+// Returning a non-nil error stops the walk and is returned to the caller
+type WalkFunc func(obj ObjectInfo) error
+
+// Backend is the common interface for all backup storage destinations
+type Backend interface {
+	// Put streams body into the object objectName. size may be -1 if unknown.
+	// metadata is stored with the object as user-defined key/value pairs.
+	Put(ctx context.Context, objectName string, body io.Reader, size int64, metadata map[string]string) (*ObjectInfo, error)
+	// Get opens the object for reading. The caller must close the returned reader.
+	Get(ctx context.Context, objectName string) (io.ReadCloser, *ObjectInfo, error)
+	// List returns all objects whose name starts with prefix
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	// Delete removes the object
+	Delete(ctx context.Context, objectName string) error
+	// Stat returns information about the object without reading it
+	Stat(ctx context.Context, objectName string) (*ObjectInfo, error)
+	// Location returns a human readable description of where objects are stored
+	Location() string
+}
+
+// Walker is implemented by backends that can stream large listings page by page
+// instead of collecting every object in memory
+type Walker interface {
+	Walk(ctx context.Context, opts ListOptions, fn WalkFunc) error
+}
+
+// Rehydrator is implemented by backends with an archive tier
+type Rehydrator interface {
+	// Rehydrate makes an archived object readable for the given number of hours,
+	// blocking and polling every pollInterval until it is
+	Rehydrate(ctx context.Context, objectName string, hours int, pollInterval time.Duration) error
+}
+
+// FileUploader is implemented by backends that can upload a local file more
+// efficiently than a plain streaming Put. UploadFile picks the object name
+// before calling it, so objectName is never empty.
+type FileUploader interface {
+	UploadFile(ctx context.Context, localPath string, objectName string, metadata map[string]string) (*UploadResult, error)
+}
+
+// UploadResumer is implemented by backends that keep local state for
+// interrupted uploads
+type UploadResumer interface {
+	// PendingObjectName returns the object name of the interrupted upload of
+	// localPath, or an empty string if there is none
+	PendingObjectName(localPath string) string
+}
+
+// FileDownloader is implemented by backends that can download to a local file
+// more efficiently than a plain streaming Get
+type FileDownloader interface {
+	DownloadFile(ctx context.Context, objectName string, localPath string) (*DownloadResult, error)
+}
+
+// UploadResult contains information about an uploaded file
+type UploadResult struct {
+	ObjectName string
+	Location   string
+	Size       int64
+	Duration   time.Duration
+	ETag       string
+}
+
+// DownloadResult contains information about a downloaded file
+type DownloadResult struct {
+	ObjectName   string
+	LocalPath    string
+	Size         int64
+	Duration     time.Duration
+	LastModified time.Time
+	Verified     string // Checksum the download was verified against (e.g. "sha256", "md5"), empty if none
+}
+
+// BackupObjectName returns the object name for a backup file using the
+// date-based folder structure: backups/YYYY/MM/filename
+func BackupObjectName(filename string, t time.Time) string {
+	return fmt.Sprintf("%s%d/%02d/%s", BackupsPrefix, t.Year(), t.Month(), filepath.Base(filename))
+}
+
+// BackupDatePrefix returns the prefix for backups of a given year and month.
+// If month is 0, the prefix covers the whole year.
+func BackupDatePrefix(year int, month int) string {
+	if month == 0 {
+		return fmt.Sprintf("%s%d/", BackupsPrefix, year)
+	}
+	return fmt.Sprintf("%s%d/%02d/", BackupsPrefix, year, month)
+}
+
+// WALObjectName returns the object name for an archived WAL file of a
+// cluster: wal/<cluster>/<file>
+func WALObjectName(cluster string, walFile string) string {
+	return fmt.Sprintf("%s%s/%s", WALPrefix, cluster, walFile)
+}
+
+// Walk calls fn for every object matching opts
+// Backends that do not implement Walker are listed in full and filtered
+func Walk(ctx context.Context, b Backend, opts ListOptions, fn WalkFunc) error {
+	// Filtering on metadata needs the metadata of every object
+	if len(opts.Metadata) > 0 {
+		if !opts.HasField(FieldMetadata) {
+			opts.Fields = append(append([]string{}, opts.Fields...), FieldMetadata)
+		}
+		next := fn
+		fn = func(obj ObjectInfo) error {
+			if !opts.MatchesMetadata(obj.Metadata) {
+				return nil
+			}
+			return next(obj)
+		}
+	}
+
+	if w, ok := b.(Walker); ok {
+		return w.Walk(ctx, opts, fn)
+	}
+
+	objects, err := b.List(ctx, opts.Prefix)
+	if err != nil {
+		return err
+	}
+
+	matching := objects[:0]
+	for _, obj := range objects {
+		if opts.Matches(obj.Name) {
+			matching = append(matching, obj)
+		}
+	}
+
+	if opts.HasField(FieldMetadata) {
+		if err := FetchMetadata(ctx, matching, b.Stat); err != nil {
+			return err
+		}
+	}
+
+	for _, obj := range matching {
+		if err := fn(obj); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// StatFunc returns information about a single object, see Backend.Stat
+type StatFunc func(ctx context.Context, objectName string) (*ObjectInfo, error)
+
+// FetchMetadata fills in the metadata of objects that have none with one
+// stat call per object, running up to MetadataConcurrency calls at a time.
+// The first error cancels the remaining calls and is returned.
+func FetchMetadata(ctx context.Context, objects []ObjectInfo, stat StatFunc) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	slots := make(chan struct{}, MetadataConcurrency)
+
+	for i := range objects {
+		if objects[i].Metadata != nil {
+			continue
+		}
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(obj *ObjectInfo) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			info, err := stat(ctx, obj.Name)
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+				return
+			}
+			obj.Metadata = info.Metadata
+		}(&objects[i])
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// UploadFile uploads a local file to the backend with optional metadata
+// If objectName is empty, an interrupted upload of the file is resumed under
+// its name; otherwise the file is placed in the backups/YYYY/MM/ layout
+func UploadFile(ctx context.Context, b Backend, localPath string, objectName string, metadata map[string]string) (*UploadResult, error) {
+	if objectName == "" {
+		if r, ok := b.(UploadResumer); ok {
+			objectName = r.PendingObjectName(localPath)
+		}
+	}
+	if objectName == "" {
+		objectName = BackupObjectName(localPath, time.Now())
+	}
+
+	if u, ok := b.(FileUploader); ok {
+		return u.UploadFile(ctx, localPath, objectName, metadata)
+	}
+
+	startTime := time.Now()
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", localPath, err)
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	info, err := b.Put(ctx, objectName, file, fileInfo.Size(), metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UploadResult{
+		ObjectName: objectName,
+		Location:   b.Location(),
+		Size:       fileInfo.Size(),
+		Duration:   time.Since(startTime),
+		ETag:       info.ETag,
+	}, nil
+}
+
+// DownloadFile downloads an object from the backend to a local file
+func DownloadFile(ctx context.Context, b Backend, objectName string, localPath string) (*DownloadResult, error) {
+	if d, ok := b.(FileDownloader); ok {
+		return d.DownloadFile(ctx, objectName, localPath)
+	}
+
+	startTime := time.Now()
+
+	body, info, err := b.Get(ctx, objectName)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	outFile, err := os.Create(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create local file %s: %w", localPath, err)
+	}
+	defer outFile.Close()
+
+	bytesWritten, err := io.Copy(outFile, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write content to file: %w", err)
+	}
+
+	return &DownloadResult{
+		ObjectName:   objectName,
+		LocalPath:    localPath,
+		Size:         bytesWritten,
+		Duration:     time.Since(startTime),
+		LastModified: info.LastModified,
+	}, nil
+}
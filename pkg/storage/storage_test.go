@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFetchMetadata(t *testing.T) {
+	objects := make([]ObjectInfo, 100)
+	for i := range objects {
+		objects[i].Name = fmt.Sprintf("backups/%03d.tar.gz", i)
+	}
+	objects[7].Metadata = map[string]string{"backup-type": "cached"}
+
+	var (
+		mu       sync.Mutex
+		calls    = make(map[string]int)
+		inFlight atomic.Int32
+		peak     atomic.Int32
+	)
+	stat := func(ctx context.Context, name string) (*ObjectInfo, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		calls[name]++
+		mu.Unlock()
+		return &ObjectInfo{Name: name, Metadata: map[string]string{"name": name}}, nil
+	}
+
+	if err := FetchMetadata(context.Background(), objects, stat); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, obj := range objects {
+		if i == 7 {
+			if obj.Metadata["backup-type"] != "cached" || calls[obj.Name] != 0 {
+				t.Errorf("existing metadata of %s was fetched again", obj.Name)
+			}
+			continue
+		}
+		if obj.Metadata["name"] != obj.Name || calls[obj.Name] != 1 {
+			t.Errorf("%s: metadata %v after %d stat calls", obj.Name, obj.Metadata, calls[obj.Name])
+		}
+	}
+	if peak.Load() > MetadataConcurrency {
+		t.Errorf("%d stat calls ran at once, limit is %d", peak.Load(), MetadataConcurrency)
+	}
+}
+
+func TestFetchMetadataStopsOnError(t *testing.T) {
+	objects := make([]ObjectInfo, 100)
+	for i := range objects {
+		objects[i].Name = fmt.Sprintf("backups/%03d.tar.gz", i)
+	}
+
+	errStat := errors.New("403 forbidden")
+	var calls atomic.Int32
+	stat := func(ctx context.Context, name string) (*ObjectInfo, error) {
+		calls.Add(1)
+		if name == "backups/003.tar.gz" {
+			return nil, errStat
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+		return &ObjectInfo{Name: name}, nil
+	}
+
+	if err := FetchMetadata(context.Background(), objects, stat); !errors.Is(err, errStat) {
+		t.Fatalf("err = %v, want %v", err, errStat)
+	}
+	if calls.Load() == int32(len(objects)) {
+		t.Error("stat was called for every object after the first error")
+	}
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// Response headers carrying the SHA-256 recorded on upload
+// OCI pre-authenticated requests return opc-meta-*, S3 presigned URLs x-amz-meta-*
+var urlChecksumHeaders = []string{
+	"Opc-Meta-" + MetaSHA256,
+	"X-Amz-Meta-" + MetaSHA256,
+}
+
+// urlClient fetches shared URLs; tests replace it to trust their server
+var urlClient = http.DefaultClient
+
+// IsURL reports whether source is an HTTPS URL, such as a pre-authenticated
+// request, rather than an object name
+func IsURL(source string) bool {
+	return strings.HasPrefix(strings.ToLower(source), "https://")
+}
+
+// URLObjectName returns the object name encoded in the last path segments of
+// a shared URL, falling back to its final path element
+func URLObjectName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	// PAR URLs end in /o/<object name>
+	if i := strings.LastIndex(u.Path, "/o/"); i >= 0 {
+		return u.Path[i+len("/o/"):]
+	}
+	return path.Base(u.Path)
+}
+
+// DownloadURL downloads a shared HTTPS URL to a local file without any
+// storage credentials. The data is written to localPath + ".part" and only
+// renamed into place once it has been verified against the SHA-256 or MD5
+// returned with the response, if any.
+func DownloadURL(ctx context.Context, rawURL string, localPath string) (*DownloadResult, error) {
+	startTime := time.Now()
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid source URL: only https:// URLs are supported")
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// The URL itself is the credential, so errors only mention the host
+	response, err := urlClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download from %s: %w", u.Host, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download from %s: %s (the link may have expired or been revoked)", u.Host, response.Status)
+	}
+
+	partPath := localPath + ".part"
+	partFile, err := os.Create(partPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create local file %s: %w", partPath, err)
+	}
+
+	sha := sha256.New()
+	sum := md5.New()
+	written, err := io.Copy(io.MultiWriter(partFile, sha, sum), response.Body)
+	if closeErr := partFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(partPath)
+		return nil, fmt.Errorf("failed to write content to file: %w", err)
+	}
+
+	if response.ContentLength >= 0 && written != response.ContentLength {
+		os.Remove(partPath)
+		return nil, fmt.Errorf("download truncated: expected %d bytes, got %d", response.ContentLength, written)
+	}
+
+	// Prefer the SHA-256 recorded on upload, then the object MD5
+	verified := ""
+	for _, header := range urlChecksumHeaders {
+		if expected := response.Header.Get(header); expected != "" {
+			if actual := hex.EncodeToString(sha.Sum(nil)); actual != expected {
+				os.Remove(partPath)
+				return nil, fmt.Errorf("%w: sha256 expected %s, got %s", ErrChecksumMismatch, expected, actual)
+			}
+			verified = "sha256"
+			break
+		}
+	}
+	if verified == "" {
+		if expected := response.Header.Get("Content-MD5"); expected != "" {
+			if actual := base64.StdEncoding.EncodeToString(sum.Sum(nil)); actual != expected {
+				os.Remove(partPath)
+				return nil, fmt.Errorf("%w: md5 expected %s, got %s", ErrChecksumMismatch, expected, actual)
+			}
+			verified = "md5"
+		}
+	}
+
+	if err := os.Rename(partPath, localPath); err != nil {
+		return nil, fmt.Errorf("failed to move download into place: %w", err)
+	}
+
+	result := &DownloadResult{
+		ObjectName: URLObjectName(rawURL),
+		LocalPath:  localPath,
+		Size:       written,
+		Duration:   time.Since(startTime),
+		Verified:   verified,
+	}
+	if lastModified, err := http.ParseTime(response.Header.Get("Last-Modified")); err == nil {
+		result.LastModified = lastModified
+	}
+
+	return result, nil
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestDownloadURL(t *testing.T) {
+	content := []byte("backup data served through a shared link")
+	sha := sha256.Sum256(content)
+	sum := md5.Sum(content)
+	goodSHA := hex.EncodeToString(sha[:])
+	goodMD5 := base64.StdEncoding.EncodeToString(sum[:])
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		body     []byte
+		length   int // Content-Length sent, 0 for len(body)
+		verified string
+		wantErr  error
+	}{
+		{name: "oci sha256", headers: map[string]string{"Opc-Meta-Sha256": goodSHA}, verified: "sha256"},
+		{name: "s3 sha256", headers: map[string]string{"X-Amz-Meta-Sha256": goodSHA}, verified: "sha256"},
+		{name: "content md5", headers: map[string]string{"Content-MD5": goodMD5}, verified: "md5"},
+		{name: "sha256 preferred over md5", headers: map[string]string{"Opc-Meta-Sha256": goodSHA, "Content-MD5": "bogus"}, verified: "sha256"},
+		{name: "no checksum"},
+		{name: "sha256 mismatch", headers: map[string]string{"Opc-Meta-Sha256": hex.EncodeToString(make([]byte, 32))}, wantErr: ErrChecksumMismatch},
+		{name: "md5 mismatch", headers: map[string]string{"Content-MD5": base64.StdEncoding.EncodeToString(make([]byte, 16))}, wantErr: ErrChecksumMismatch},
+		{name: "truncated", headers: map[string]string{"Opc-Meta-Sha256": goodSHA}, body: content[:10], length: len(content)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := tt.body
+			if body == nil {
+				body = content
+			}
+			length := tt.length
+			if length == 0 {
+				length = len(body)
+			}
+
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for key, value := range tt.headers {
+					w.Header().Set(key, value)
+				}
+				w.Header().Set("Content-Length", strconv.Itoa(length))
+				w.Write(body)
+			}))
+			defer server.Close()
+
+			client := urlClient
+			urlClient = server.Client()
+			defer func() { urlClient = client }()
+
+			localPath := filepath.Join(t.TempDir(), "db.tar.gz")
+			result, err := DownloadURL(t.Context(), server.URL+"/p/secret/n/ns/b/backups/o/backups/db.tar.gz", localPath)
+
+			failed := tt.wantErr != nil || len(body) != length
+			if !failed {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result.Verified != tt.verified {
+					t.Errorf("verified = %q, want %q", result.Verified, tt.verified)
+				}
+				if result.ObjectName != "backups/db.tar.gz" {
+					t.Errorf("object name = %q", result.ObjectName)
+				}
+				data, err := os.ReadFile(localPath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(data) != string(content) {
+					t.Errorf("content = %q", data)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("DownloadURL succeeded")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			for _, path := range []string{localPath, localPath + ".part"} {
+				if _, err := os.Stat(path); !os.IsNotExist(err) {
+					t.Errorf("%s left behind after a failed download", path)
+				}
+			}
+		})
+	}
+}
+
+func TestDownloadURLRejectsPlainHTTP(t *testing.T) {
+	localPath := filepath.Join(t.TempDir(), "db.tar.gz")
+	if _, err := DownloadURL(t.Context(), "http://example.com/o/db.tar.gz", localPath); err == nil {
+		t.Fatal("DownloadURL accepted an http:// URL")
+	}
+}